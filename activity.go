@@ -1,7 +1,9 @@
 package feeder
 
 import (
-	"log"
+	"context"
+	"errors"
+	"math"
 	"time"
 )
 
@@ -13,78 +15,427 @@ type Activity struct {
 	Feed     *Feed    `json:"feed"`
 }
 
+// backend returns the feeds backend scoped to the feeds name and size so that feeds
+// sharing a backend never read or trim each others events
+func (a Activity) backend() Backend {
+	return a.Feed.P.WithFeed(a.Feed.Name, a.Feed.Size)
+}
+
+// notSupported is the error of an operation the backend of the feed does not implement
+func (a Activity) notSupported(op string) error {
+	return opError(op, a.Feed.Name, a.UserID, ErrNotSupported)
+}
+
 // Wipe removes all events made by a user/app in a feed
-func (a Activity) Wipe() (int64, error) {
+func (a Activity) Wipe(ctx context.Context) (int64, error) {
+
+	res, err := a.backend().Wipe(ctx, a.UserID)
+
+	return res, err
+}
+
+// Store stores an event under a new ID, or under its value when the feed dedupes events.
+// The event is scored by the Scorer of the feed
+func (a Activity) Store(ctx context.Context, value string, at int64) (int64, error) {
+
+	score := a.Feed.scoreItem(Item{Value: value, At: time.Unix(at, 0)}, time.Now())
+
+	res, err := a.backend().StoreID(ctx, a.UserID, a.Feed.newID(value, at), value, at, score)
+
+	return res, err
+}
+
+// StoreAction encodes an action with the codec of the feed and stores it at the time it happened.
+// When the feed has a GroupWindow an action with a method joins the group of its method and object
+func (a Activity) StoreAction(ctx context.Context, action Action) (int64, error) {
+
+	value, err := EncodeAction(a.Feed.codec(), action)
+
+	if err != nil {
+		return 0, opError("store", a.Feed.Name, a.UserID, err)
+	}
+
+	at := action.At.Unix()
+	score := a.Feed.score(action, time.Now())
+
+	if a.Feed.GroupWindow <= 0 || action.Method == "" {
+		return a.backend().StoreID(ctx, a.UserID, a.Feed.newID(value, at), value, at, score)
+	}
+
+	grouper, ok := a.backend().(GroupBackend)
+
+	if !ok {
+		return 0, a.notSupported("store")
+	}
+
+	return grouper.StoreGroup(ctx, a.UserID, NewID(action.At), value, at, score,
+		groupKey(action), action.Actor, windowSeconds(a.Feed.GroupWindow))
+}
+
+// Delete removes the event with the ID of an Item and reports whether it existed
+func (a Activity) Delete(ctx context.Context, id string) (bool, error) {
+
+	res, err := a.backend().DeleteID(ctx, a.UserID, id)
+
+	return res, err
+}
+
+// Update replaces the value of an event, keeping its place in the feed, and reports whether it existed
+func (a Activity) Update(ctx context.Context, id, value string) (bool, error) {
+
+	editor, ok := a.backend().(EditBackend)
+
+	if !ok {
+		return false, a.notSupported("update")
+	}
+
+	res, err := editor.Update(ctx, a.UserID, id, value)
+
+	return res, err
+}
+
+// UpdateAction replaces an event with an action encoded with the codec of the feed,
+// the event keeps its place in the feed
+func (a Activity) UpdateAction(ctx context.Context, id string, action Action) (bool, error) {
+
+	value, err := EncodeAction(a.Feed.codec(), action)
+
+	if err != nil {
+		return false, opError("update", a.Feed.Name, a.UserID, err)
+	}
+
+	return a.Update(ctx, id, value)
+}
+
+// Move moves an event to a new time and reports whether it existed. On a feed with a Scorer
+// the event keeps its score until the feed is rescored
+func (a Activity) Move(ctx context.Context, id string, at int64) (bool, error) {
+
+	editor, ok := a.backend().(EditBackend)
 
-	res, err := a.Feed.P.Wipe(a.UserID)
+	if !ok {
+		return false, a.notSupported("move")
+	}
+
+	res, err := editor.Move(ctx, a.UserID, id, at)
 
 	return res, err
 }
 
-func (a Activity) Store(value string, at int64) (int64, error) {
+// Pin pins an event to the top of Peek, Read and All until the time until, the zero time pins it
+// until it is unpinned. Pinned events are not trimmed, reports whether the event existed
+func (a Activity) Pin(ctx context.Context, id string, until time.Time) (bool, error) {
+
+	pinner, ok := a.backend().(PinBackend)
 
-	res, err := a.Feed.P.Store(a.UserID, value, at)
+	if !ok {
+		return false, a.notSupported("pin")
+	}
+
+	var at int64
+	if !until.IsZero() {
+		at = until.Unix()
+	}
+
+	res, err := pinner.Pin(ctx, a.UserID, id, at)
 
 	return res, err
 }
 
-func (a Activity) Count() (int, error)  {
+// Unpin unpins an event and reports whether it existed, the feed is trimmed back to its size
+// by the next store
+func (a Activity) Unpin(ctx context.Context, id string) (bool, error) {
+
+	pinner, ok := a.backend().(PinBackend)
 
-	res, err := a.Feed.P.Count(a.UserID)
+	if !ok {
+		return false, a.notSupported("unpin")
+	}
+
+	res, err := pinner.Unpin(ctx, a.UserID, id)
 
 	return res, err
 }
 
-func (a Activity) ResetLastRead(at int64) (bool, error) {
+// SetPriority sets the priority of an event and reports whether it existed. Events are trimmed
+// lowest priority first and then lowest in the feed, events have priority 0 until it is set
+func (a Activity) SetPriority(ctx context.Context, id string, priority int) (bool, error) {
+
+	pinner, ok := a.backend().(PinBackend)
+
+	if !ok {
+		return false, a.notSupported("set priority")
+	}
 
-	res, err := a.Feed.P.ResetLastRead(a.UserID, at)
+	res, err := pinner.SetPriority(ctx, a.UserID, id, priority)
 
 	return res, err
 }
 
+// Rescore scores every event anew with the Scorer of the feed at now and returns the number
+// of events rescored, the events keep the time they happened at. Feeds whose scores change
+// with time, such as those ranked by Decay, are rescored regularly, see BatchActivity.Rescore
+func (a Activity) Rescore(ctx context.Context, now time.Time) (int64, error) {
 
+	rescorer, ok := a.backend().(RescoreBackend)
 
-func (a Activity) UnreadCount() (int64, error) {
+	if !ok {
+		return 0, a.notSupported("rescore")
+	}
 
-	lastRead, err := a.Feed.P.LastRead(a.UserID)
+	items, err := a.All(ctx)
 
 	if err != nil {
-		log.Printf("failed to get last read %s \n", err)
+		return 0, err
 	}
 
-	res, err := a.Feed.P.UnRead(a.UserID, lastRead)
+	scores := make(map[string]float64, len(items))
+
+	for _, item := range items {
+		scores[item.ID] = a.Feed.scoreItem(item, now)
+	}
+
+	res, err := rescorer.Rescore(ctx, a.UserID, scores)
 
 	return res, err
 }
 
-func (a Activity) Paginate(page, perPage int) ([]string, error) {
+func (a Activity) Count(ctx context.Context) (int, error)  {
 
-	res, err := a.Feed.P.Paginate(a.UserID, page, perPage)
+	res, err := a.backend().Count(ctx, a.UserID)
 
-	if err == nil {
+	return res, err
+}
 
-		res, err := a.Feed.P.ResetLastRead(a.UserID, time.Now().Unix())
+func (a Activity) ResetLastRead(ctx context.Context, at int64) (bool, error) {
 
-		if err == nil {
-			if res == true {
-				log.Println("key does not exist, a new key holding a hash was created")
-			} else {
-				log.Println("field already exists in the hash, it has been overwritten.")
-			}
-		}
+	res, err := a.backend().ResetLastRead(ctx, a.UserID, at)
 
+	return res, err
+}
+
+
+
+// MarkRead marks the events with the IDs of items read and returns the number that were unread
+func (a Activity) MarkRead(ctx context.Context, ids ...string) (int64, error) {
+
+	marker, ok := a.backend().(MarkBackend)
+
+	if !ok {
+		return 0, a.notSupported("mark read")
 	}
 
+	res, err := marker.MarkRead(ctx, a.UserID, ids...)
+
 	return res, err
 }
 
-func (a Activity) All() ([]string, error) {
+// MarkUnread marks the events with the IDs of items unread and returns the number that were read
+func (a Activity) MarkUnread(ctx context.Context, ids ...string) (int64, error) {
+
+	marker, ok := a.backend().(MarkBackend)
 
-	res, err := a.Feed.P.All(a.UserID)
+	if !ok {
+		return 0, a.notSupported("mark unread")
+	}
+
+	res, err := marker.MarkUnread(ctx, a.UserID, ids...)
 
 	return res, err
 }
 
+// MarkAllReadUpTo marks an event and every event before it read, newer events keep their state
+func (a Activity) MarkAllReadUpTo(ctx context.Context, id string) (bool, error) {
+
+	marker, ok := a.backend().(MarkBackend)
+
+	if !ok {
+		return false, a.notSupported("mark read")
+	}
+
+	res, err := marker.MarkAllReadUpTo(ctx, a.UserID, id)
+
+	return res, err
+}
+
+// UnreadCount returns the number of events stored since the user last read the feed and the
+// events marked unread, less the events marked read. The count is kept as events are stored,
+// trimmed, deleted and read so it costs a single read. A user that never read the feed has
+// every event unread
+func (a Activity) UnreadCount(ctx context.Context) (int64, error) {
+
+	res, err := a.backend().UnreadCount(ctx, a.UserID)
+
+	if errors.Is(err, ErrUserNotFound) {
+		return 0, nil
+	}
+
+	return res, err
+}
+
+// RecalculateUnread recounts the unread events into the unread count when it drifted,
+// such as for feeds stored before the count was kept
+func (a Activity) RecalculateUnread(ctx context.Context) (int64, error) {
+
+	res, err := a.backend().RecalculateUnread(ctx, a.UserID)
+
+	return res, err
+}
+
+// Peek returns a page of events, newest first, without marking the feed as read
+func (a Activity) Peek(ctx context.Context, page, perPage int) ([]Item, error) {
+
+	res, err := a.backend().Paginate(ctx, a.UserID, page, perPage)
+
+	return res, err
+}
+
+// Read returns a page of events, newest first, and marks the feed as read
+func (a Activity) Read(ctx context.Context, page, perPage int) ([]Item, error) {
+
+	res, err := a.Peek(ctx, page, perPage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := a.backend().ResetLastRead(ctx, a.UserID, time.Now().Unix()); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// After returns up to limit events older than the cursor, newest first, without marking the feed
+// as read. The empty cursor starts at the newest event, pass the Next cursor of a page to read on
+func (a Activity) After(ctx context.Context, cursor string, limit int) (Page, error) {
+
+	position, err := a.decodeCursor(cursor)
+
+	if err != nil {
+		return Page{}, err
+	}
+
+	items, err := a.backend().After(ctx, a.UserID, position, limit)
+
+	if err != nil {
+		return Page{}, err
+	}
+
+	return a.page(items, cursor), nil
+}
+
+// Before returns up to limit events newer than the cursor, newest first, without marking the feed
+// as read. Pass the Prev cursor of a page to read the events that arrived since
+func (a Activity) Before(ctx context.Context, cursor string, limit int) (Page, error) {
+
+	position, err := a.decodeCursor(cursor)
+
+	if err != nil {
+		return Page{}, err
+	}
+
+	items, err := a.backend().Before(ctx, a.UserID, position, limit)
+
+	if err != nil {
+		return Page{}, err
+	}
+
+	return a.page(items, cursor), nil
+}
+
+// Between returns the events that happened at or after from and before to, newest first,
+// times are compared to the second. The events of a feed with a Scorer are in the order of the feed
+func (a Activity) Between(ctx context.Context, from, to time.Time) ([]Item, error) {
+
+	res, err := a.backend().Between(ctx, a.UserID, from.Unix(), to.Unix())
+
+	return res, err
+}
+
+// Since returns the events that happened at or after t, newest first
+func (a Activity) Since(ctx context.Context, t time.Time) ([]Item, error) {
+
+	res, err := a.backend().Between(ctx, a.UserID, t.Unix(), math.MaxInt64)
+
+	return res, err
+}
+
+// Until returns the events that happened before t, newest first
+func (a Activity) Until(ctx context.Context, t time.Time) ([]Item, error) {
+
+	res, err := a.backend().Between(ctx, a.UserID, math.MinInt64, t.Unix())
+
+	return res, err
+}
+
+// decodeCursor checks that the cursor was handed out for the users feed
+func (a Activity) decodeCursor(cursor string) (Cursor, error) {
+	position, err := decodeCursor(cursorKey(a.Feed), a.Feed.Name, a.UserID, cursor)
+
+	return position, opError("cursor", a.Feed.Name, a.UserID, err)
+}
+
+// page makes a page of items read with cursor
+func (a Activity) page(items []Item, cursor string) Page {
+	page := Page{Items: items, Next: cursor, Prev: cursor}
+
+	if len(items) > 0 {
+		key := cursorKey(a.Feed)
+		page.Prev = encodeCursor(key, a.Feed.Name, a.UserID, items[0].Cursor())
+		page.Next = encodeCursor(key, a.Feed.Name, a.UserID, items[len(items)-1].Cursor())
+	}
+
+	return page
+}
+
+// Paginate returns a page of events, newest first, and marks the feed as read
+//
+// Deprecated: use Read, or Peek to leave the feed unread
+func (a Activity) Paginate(ctx context.Context, page, perPage int) ([]Item, error) {
+	return a.Read(ctx, page, perPage)
+}
+
+func (a Activity) All(ctx context.Context) ([]Item, error) {
+
+	res, err := a.backend().All(ctx, a.UserID)
+
+	return res, err
+}
+
+// Event hydrates an Event with every event of the user, the count and the last read time stamp,
+// a user without events or who never read the feed has them left at zero
+func (a Activity) Event(ctx context.Context) (*Event, error) {
+
+	items, err := a.All(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	event := NewEvent(a.UserID)
+
+	for _, item := range items {
+		action, err := item.Action()
+
+		if err != nil {
+			return nil, opError("event", a.Feed.Name, a.UserID, err)
+		}
+
+		event.Activity = append(event.Activity, action)
+	}
+
+	if event.Count, err = a.Count(ctx); err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	if event.LastRead, err = a.backend().LastRead(ctx, a.UserID); err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	return event, nil
+}
+
 
 // NewActivity instantiates a new user activity
 func NewActivity(id string, feed *Feed) *Activity {
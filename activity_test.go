@@ -1,6 +1,10 @@
 package feeder
 
 import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
 
 	"testing"
 	"github.com/google/go-cmp/cmp"
@@ -57,6 +61,8 @@ func TestNewActivity(t *testing.T) {
 
 func TestActivityWipe(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 	if err != nil {
 		panic(err)
@@ -75,7 +81,7 @@ func TestActivityWipe(t *testing.T) {
 
 	activity := NewActivity(userID, feed)
 
-	got, err  := activity.Wipe()
+	got, err  := activity.Wipe(ctx)
 
 	if err != nil {
 		t.Errorf("activity wipe error %s", err)
@@ -91,6 +97,8 @@ func TestActivityWipe(t *testing.T) {
 
 func TestActivityStore(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 	if err != nil {
 		panic(err)
@@ -110,7 +118,7 @@ func TestActivityStore(t *testing.T) {
 
 	activity := NewActivity(userID, feed)
 
-	got, err := activity.Store("this is the first value store", time.Now().Unix())
+	got, err := activity.Store(ctx, "this is the first value store", time.Now().Unix())
 
 	expected := int64(1)
 
@@ -118,7 +126,7 @@ func TestActivityStore(t *testing.T) {
 		t.Errorf("failed to store to  user feed using activity got %v but wanted %v", got, expected)
 	}
 
-	count, err  := activity.Count()
+	count, err  := activity.Count(ctx)
 
 	if err != nil {
 		t.Errorf("activity count error %s", err)
@@ -136,6 +144,8 @@ func TestActivityStore(t *testing.T) {
 }
 
 func TestActivityCount(t *testing.T) {
+
+	ctx := context.Background()
 	// test setup
 	server, err := miniredis.Run()
 	if err != nil {
@@ -156,13 +166,13 @@ func TestActivityCount(t *testing.T) {
 
 	activity := NewActivity(userID, feed)
 
-	activity.Store("this is the first value store", time.Now().Unix())
-	activity.Store("this is the second value store", time.Now().Unix())
+	activity.Store(ctx, "this is the first value store", time.Now().Unix())
+	activity.Store(ctx, "this is the second value store", time.Now().Unix())
 
 
 
 	// testing
-	count, err  := activity.Count()
+	count, err  := activity.Count(ctx)
 
 	if err != nil {
 		t.Errorf("activity count error %s", err)
@@ -180,6 +190,8 @@ func TestActivityCount(t *testing.T) {
 }
 
 func TestActivityUnread(t *testing.T) {
+
+	ctx := context.Background()
 	// test setup
 	server, err := miniredis.Run()
 	if err != nil {
@@ -203,17 +215,17 @@ func TestActivityUnread(t *testing.T) {
 
 	unix := time.Now().Add(time.Duration(-2) * time.Minute).Unix()
 
-	activity.ResetLastRead(unix)
+	activity.ResetLastRead(ctx, unix)
 
-	activity.Store("this is the first value store", time.Now().Add(time.Duration(-3) * time.Minute).Unix())
-	activity.Store("this is the second value store", time.Now().Unix())
-	activity.Store("this is the third value store", time.Now().Unix())
-	activity.Store("this is the four value store", time.Now().Unix())
+	activity.Store(ctx, "this is the first value store", time.Now().Add(time.Duration(-3) * time.Minute).Unix())
+	activity.Store(ctx, "this is the second value store", time.Now().Unix())
+	activity.Store(ctx, "this is the third value store", time.Now().Unix())
+	activity.Store(ctx, "this is the four value store", time.Now().Unix())
 
 
 	want := int64(3)
 
-	got, err := activity.UnreadCount()
+	got, err := activity.UnreadCount(ctx)
 
 	if err != nil {
 		t.Errorf("Unread count error %s", err)
@@ -226,6 +238,8 @@ func TestActivityUnread(t *testing.T) {
 }
 
 func TestActivityPagination(t *testing.T) {
+
+	ctx := context.Background()
 	// test setup
 	server, err := miniredis.Run()
 	if err != nil {
@@ -248,18 +262,18 @@ func TestActivityPagination(t *testing.T) {
 	activity := NewActivity(userID, feed)
 
 
-	activity.Store("this is the first value store", time.Now().Unix())
-	activity.Store("this is the second value store", time.Now().Unix())
-	activity.Store("this is the third value store", time.Now().Unix())
-	activity.Store("this is the four value store", time.Now().Unix())
-	activity.Store("this is the five value store", time.Now().Unix())
-	activity.Store("this is the six value store", time.Now().Unix())
-	activity.Store("this is the seven value store", time.Now().Unix())
+	activity.Store(ctx, "this is the first value store", time.Now().Unix())
+	activity.Store(ctx, "this is the second value store", time.Now().Unix())
+	activity.Store(ctx, "this is the third value store", time.Now().Unix())
+	activity.Store(ctx, "this is the four value store", time.Now().Unix())
+	activity.Store(ctx, "this is the five value store", time.Now().Unix())
+	activity.Store(ctx, "this is the six value store", time.Now().Unix())
+	activity.Store(ctx, "this is the seven value store", time.Now().Unix())
 
 
 	want := 5
 
-	got, err := activity.Paginate(1, 5)
+	got, err := activity.Paginate(ctx, 1, 5)
 
 	if err != nil {
 		t.Errorf("pagination error %s", err)
@@ -272,6 +286,8 @@ func TestActivityPagination(t *testing.T) {
 }
 
 func TestActivityAll(t *testing.T) {
+
+	ctx := context.Background()
 	// test setup
 	server, err := miniredis.Run()
 	if err != nil {
@@ -294,18 +310,18 @@ func TestActivityAll(t *testing.T) {
 	activity := NewActivity(userID, feed)
 
 
-	activity.Store("this is the first value store", time.Now().Unix())
-	activity.Store("this is the second value store", time.Now().Unix())
-	activity.Store("this is the third value store", time.Now().Unix())
-	activity.Store("this is the four value store", time.Now().Unix())
-	activity.Store("this is the five value store", time.Now().Unix())
-	activity.Store("this is the six value store", time.Now().Unix())
-	activity.Store("this is the seven value store", time.Now().Unix())
+	activity.Store(ctx, "this is the first value store", time.Now().Unix())
+	activity.Store(ctx, "this is the second value store", time.Now().Unix())
+	activity.Store(ctx, "this is the third value store", time.Now().Unix())
+	activity.Store(ctx, "this is the four value store", time.Now().Unix())
+	activity.Store(ctx, "this is the five value store", time.Now().Unix())
+	activity.Store(ctx, "this is the six value store", time.Now().Unix())
+	activity.Store(ctx, "this is the seven value store", time.Now().Unix())
 
 
 	want := 7
 
-	got, err := activity.All()
+	got, err := activity.All(ctx)
 
 	if err != nil {
 		t.Errorf("all error %s", err)
@@ -317,3 +333,758 @@ func TestActivityAll(t *testing.T) {
 
 }
 
+
+func TestActivityFeedsShareBackend(t *testing.T) {
+
+	ctx := context.Background()
+	// test setup
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	client := NewRedisClient(c)
+
+	userID := "okandas"
+
+	news := NewActivity(userID, NewFeed("news", 5, 2, client))
+	notifications := NewActivity(userID, NewFeed("notifications", 3, 2, client))
+
+	for i := 0; i < 7; i++ {
+		news.Store(ctx, "news "+string(rune('a'+i)), int64(i))
+		notifications.Store(ctx, "notification "+string(rune('a'+i)), int64(i))
+	}
+
+	tt := []struct {
+		name     string
+		activity *Activity
+		want     []string
+	}{
+		{name: "news", activity: news, want: []string{"news g", "news f", "news e", "news d", "news c"}},
+		{name: "notifications", activity: notifications, want: []string{"notification g", "notification f", "notification e"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			got, err := tc.activity.All(ctx)
+
+			if err != nil {
+				t.Errorf("all error %s", err)
+				return
+			}
+
+			if !cmp.Equal(itemValues(got), tc.want) {
+				t.Errorf("got %v want %v", itemValues(got), tc.want)
+			}
+
+			count, err := tc.activity.Count(ctx)
+
+			if err != nil {
+				t.Errorf("count error %s", err)
+				return
+			}
+
+			if count != len(tc.want) {
+				t.Errorf("count got %d want %d", count, len(tc.want))
+			}
+
+		})
+	}
+
+}
+
+func TestActivityUnreadNeverRead(t *testing.T) {
+
+	ctx := context.Background()
+
+	activity := NewActivity("okandas", NewFeed("notifications", 10, 15, NewMemoryBackend()))
+
+	activity.Store(ctx, "this is the first value store", time.Now().Unix())
+	activity.Store(ctx, "this is the second value store", time.Now().Unix())
+
+	want := int64(2)
+
+	got, err := activity.UnreadCount(ctx)
+
+	if err != nil {
+		t.Errorf("Unread count error %s", err)
+	}
+
+	if got != want {
+		t.Errorf("got %d want %d", got, want)
+	}
+
+}
+
+func TestActivityPeek(t *testing.T) {
+
+	ctx := context.Background()
+
+	activity := NewActivity("okandas", NewFeed("notifications", 10, 15, NewMemoryBackend()))
+
+	at := time.Now().Unix() - 100
+
+	activity.Store(ctx, "this is the first value store", at)
+	activity.Store(ctx, "this is the second value store", at+1)
+
+	tt := []struct {
+		name   string
+		read   func(ctx context.Context, page, perPage int) ([]Item, error)
+		unread int64
+	}{
+		{"peek", activity.Peek, 2},
+		{"read", activity.Read, 0},
+	}
+
+	for _, tc := range tt {
+		got, err := tc.read(ctx, 1, 1)
+
+		if err != nil {
+			t.Errorf("%s error %s", tc.name, err)
+		}
+
+		if len(got) != 1 || got[0].Value != "this is the second value store" {
+			t.Errorf("%s got %v want the newest event", tc.name, got)
+		}
+
+		unread, err := activity.UnreadCount(ctx)
+
+		if err != nil {
+			t.Errorf("Unread count error %s", err)
+		}
+
+		if unread != tc.unread {
+			t.Errorf("unread after %s got %d want %d", tc.name, unread, tc.unread)
+		}
+	}
+
+}
+
+func TestActivityEvent(t *testing.T) {
+
+	ctx := context.Background()
+
+	activity := NewActivity("okandas", NewFeed("notifications", 10, 15, NewMemoryBackend()))
+
+	at := time.Now().Unix() - 100
+
+	activity.Store(ctx, "this is the first value store", at)
+	activity.Store(ctx, "this is the second value store", at+1)
+	activity.ResetLastRead(ctx, at)
+
+	got, err := activity.Event(ctx)
+
+	if err != nil {
+		t.Errorf("event error %s", err)
+		return
+	}
+
+	want := &Event{
+		UserID: "okandas",
+		Activity: []Action{
+			{Value: "this is the second value store", At: time.Unix(at+1, 0)},
+			{Value: "this is the first value store", At: time.Unix(at, 0)},
+		},
+		Count:    2,
+		LastRead: at,
+	}
+
+	if !cmp.Equal(got, want) {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+
+}
+
+func TestActivityCursor(t *testing.T) {
+
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	activity := NewActivity("okandas", NewFeed("notifications", 20, 15, NewRedisClient(c)))
+
+	for i := 0; i < 6; i++ {
+		activity.Store(ctx, "event "+string(rune('a'+i)), int64(100+i))
+	}
+
+	var got []string
+	cursor := ""
+
+	for {
+		page, err := activity.After(ctx, cursor, 2)
+
+		if err != nil {
+			t.Errorf("after error %s", err)
+			return
+		}
+
+		if len(page.Items) == 0 {
+			if page.Next != cursor {
+				t.Errorf("empty page moved the cursor")
+			}
+			break
+		}
+
+		got = append(got, itemValues(page.Items)...)
+		cursor = page.Next
+
+		// events arriving while scrolling do not shift the pages
+		activity.Store(ctx, "new "+strconv.Itoa(len(got)), int64(200+len(got)))
+	}
+
+	want := []string{"event f", "event e", "event d", "event c", "event b", "event a"}
+
+	if !cmp.Equal(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	first, _ := activity.After(ctx, "", 1)
+	newer, err := activity.Before(ctx, first.Next, 10)
+
+	if err != nil || len(newer.Items) != 0 {
+		t.Errorf("before the newest event got %v %v want no events", newer.Items, err)
+	}
+
+	if _, err := activity.After(ctx, "forged", 2); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("after a forged cursor got %v want %v", err, ErrInvalidCursor)
+	}
+
+	other := NewActivity("tendai", activity.Feed)
+
+	if _, err := other.After(ctx, first.Next, 2); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("after a cursor of another user got %v want %v", err, ErrInvalidCursor)
+	}
+
+}
+
+func TestActivityTimeRange(t *testing.T) {
+
+	ctx := context.Background()
+
+	activity := NewActivity("okandas", NewFeed("notifications", 10, 15, NewMemoryBackend()))
+
+	week := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	activity.Store(ctx, "last week", week.Add(-day).Unix())
+	activity.Store(ctx, "monday", week.Unix())
+	activity.Store(ctx, "sunday", week.Add(7*day-time.Second).Unix())
+	activity.Store(ctx, "next week", week.Add(7*day).Unix())
+
+	tt := []struct {
+		name  string
+		query func() ([]Item, error)
+		want  []string
+	}{
+		{
+			name:  "between",
+			query: func() ([]Item, error) { return activity.Between(ctx, week, week.Add(7*day)) },
+			want:  []string{"sunday", "monday"},
+		},
+		{
+			name:  "since",
+			query: func() ([]Item, error) { return activity.Since(ctx, week.Add(day)) },
+			want:  []string{"next week", "sunday"},
+		},
+		{
+			name:  "until",
+			query: func() ([]Item, error) { return activity.Until(ctx, week.Add(day)) },
+			want:  []string{"monday", "last week"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			got, err := tc.query()
+
+			if err != nil {
+				t.Errorf("%s error %s", tc.name, err)
+				return
+			}
+
+			if !cmp.Equal(itemValues(got), tc.want) {
+				t.Errorf("got %v want %v", itemValues(got), tc.want)
+			}
+
+		})
+	}
+
+}
+
+func TestActivityStoreAction(t *testing.T) {
+
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	feed := NewFeed("notifications", 10, 15, NewRedisClient(c))
+	feed.Codec = MessagePack
+
+	activity := NewActivity("okandas", feed)
+
+	action := Action{
+		Value:  "tendai followed you",
+		At:     time.Unix(1700000000, 0),
+		Method: "follow",
+		Meta:   map[string]string{"follower": "tendai"},
+	}
+
+	activity.Store(ctx, "a plain value", 1600000000)
+
+	if _, err := activity.StoreAction(ctx, action); err != nil {
+		t.Errorf("store action error %s", err)
+		return
+	}
+
+	event, err := activity.Event(ctx)
+
+	if err != nil {
+		t.Errorf("event error %s", err)
+		return
+	}
+
+	want := []Action{action, {Value: "a plain value", At: time.Unix(1600000000, 0)}}
+
+	if !cmp.Equal(event.Activity, want) {
+		t.Errorf("got %+v want %+v", event.Activity, want)
+	}
+
+}
+
+func TestActivityDedupe(t *testing.T) {
+
+	ctx := context.Background()
+
+	tt := []struct {
+		name   string
+		dedupe bool
+		want   []string
+	}{
+		{name: "keeps every event", dedupe: false, want: []string{"tendai liked your photo", "tendai liked your photo"}},
+		{name: "collapses equal values", dedupe: true, want: []string{"tendai liked your photo"}},
+	}
+
+	for _, tc := range tt {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			feed := NewFeed("notifications", 10, 15, NewMemoryBackend())
+			feed.Dedupe = tc.dedupe
+
+			activity := NewActivity("okandas", feed)
+
+			activity.Store(ctx, "tendai liked your photo", 1700000000)
+			activity.Store(ctx, "tendai liked your photo", 1700000000)
+
+			got, err := activity.All(ctx)
+
+			if err != nil {
+				t.Errorf("all error %s", err)
+				return
+			}
+
+			if !cmp.Equal(itemValues(got), tc.want) {
+				t.Errorf("got %v want %v", itemValues(got), tc.want)
+			}
+
+			if len(got) == 2 && got[0].ID <= got[1].ID {
+				t.Errorf("got IDs %s and %s want the newest first", got[0].ID, got[1].ID)
+			}
+
+		})
+	}
+}
+
+func TestActivityEdit(t *testing.T) {
+
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	feed := NewFeed("notifications", 10, 15, NewRedisClient(c))
+	activity := NewActivity("okandas", feed)
+
+	activity.Store(ctx, "tendai liked your photo", 1700000000)
+	activity.Store(ctx, "tendai liked your photo", 1700000100)
+	activity.Store(ctx, "rudo followed you", 1700000200)
+	activity.ResetLastRead(ctx, 1700000100)
+
+	items, _ := activity.All(ctx)
+
+	unread := func() string {
+		return server.HGet("notifications:okandas.meta", "unread_count")
+	}
+
+	// the oldest event was read so deleting it leaves the unread count alone
+	if deleted, err := activity.Delete(ctx, items[2].ID); err != nil || !deleted {
+		t.Errorf("delete got %t, %v want the event deleted", deleted, err)
+	}
+
+	if got := unread(); got != "2" {
+		t.Errorf("unread count after deleting a read event got %s want %s", got, "2")
+	}
+
+	if deleted, _ := activity.Delete(ctx, items[2].ID); deleted {
+		t.Errorf("deleting an event twice reported it existed")
+	}
+
+	// moving an unread event before the last read marks it read
+	if moved, err := activity.Move(ctx, items[0].ID, 1600000000); err != nil || !moved {
+		t.Errorf("move got %t, %v want the event moved", moved, err)
+	}
+
+	if got := unread(); got != "1" {
+		t.Errorf("unread count after moving an event back got %s want %s", got, "1")
+	}
+
+	action := Action{Value: "tendai loved your photo", At: time.Unix(1700000100, 0)}
+
+	if updated, err := activity.UpdateAction(ctx, items[1].ID, action); err != nil || !updated {
+		t.Errorf("update got %t, %v want the event updated", updated, err)
+	}
+
+	event, _ := activity.Event(ctx)
+	want := []string{"tendai loved your photo", "rudo followed you"}
+
+	if len(event.Activity) != 2 || event.Activity[0].Value != want[0] || event.Activity[1].Value != want[1] {
+		t.Errorf("got %+v want %v", event.Activity, want)
+	}
+
+	if count, _ := activity.Count(ctx); count != 2 {
+		t.Errorf("count got %d want %d", count, 2)
+	}
+
+}
+
+func TestActivityMarks(t *testing.T) {
+
+	ctx := context.Background()
+
+	activity := NewActivity("okandas", NewFeed("notifications", 10, 15, NewMemoryBackend()))
+
+	for i := 0; i < 4; i++ {
+		activity.Store(ctx, "tendai liked your photo", int64(1700000000+i))
+	}
+	activity.ResetLastRead(ctx, 1700000002)
+
+	items, _ := activity.All(ctx)
+
+	activity.MarkRead(ctx, items[0].ID)
+	activity.MarkUnread(ctx, items[3].ID)
+
+	tt := []struct {
+		name string
+		mark func()
+		want int64
+	}{
+		{name: "counts the watermark and the marks", mark: func() {}, want: 2},
+		{name: "marks up to an event", mark: func() { activity.MarkAllReadUpTo(ctx, items[2].ID) }, want: 1},
+		{name: "reads the feed", mark: func() { activity.Read(ctx, 1, 10) }, want: 0},
+	}
+
+	for _, tc := range tt {
+		tc.mark()
+
+		if got, err := activity.UnreadCount(ctx); err != nil || got != tc.want {
+			t.Errorf("%s got %d, %v want %d", tc.name, got, err, tc.want)
+		}
+	}
+
+}
+
+func TestActivityGroups(t *testing.T) {
+
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	feed := NewFeed("notifications", 3, 15, NewRedisClient(c))
+	feed.GroupWindow = time.Hour
+
+	activity := NewActivity("okandas", feed)
+
+	at := time.Unix(1700000000, 0)
+
+	for i, actor := range []string{"tendai", "rudo", "tendai", "farai"} {
+		activity.StoreAction(ctx, Action{
+			Value:  actor + " liked your photo",
+			At:     at.Add(time.Duration(i) * time.Minute),
+			Method: "like",
+			Actor:  actor,
+			Object: "photo:42",
+		})
+	}
+
+	activity.StoreAction(ctx, Action{Value: "rudo followed you", At: at, Method: "follow", Actor: "rudo"})
+	activity.StoreAction(ctx, Action{Value: "welcome", At: at})
+
+	items, err := activity.Peek(ctx, 1, 10)
+
+	if err != nil {
+		t.Errorf("peek error %s", err)
+		return
+	}
+
+	if len(items) != 3 || items[0].Group == nil {
+		t.Errorf("got %+v want the likes grouped", items)
+		return
+	}
+
+	want := Group{Actors: []string{"farai", "tendai", "rudo"}, Count: 4, First: at}
+
+	if !cmp.Equal(*items[0].Group, want) {
+		t.Errorf("got group %+v want %+v", *items[0].Group, want)
+	}
+
+	if action, _ := items[0].Action(); action.Value != "farai liked your photo" || !items[0].At.Equal(at.Add(3*time.Minute)) {
+		t.Errorf("got %+v at %s want the latest like", action, items[0].At)
+	}
+
+	// the welcome was stored after the follow at the same time so it comes first
+	if items[1].Group != nil || items[2].Group == nil {
+		t.Errorf("got groups %+v and %+v want only the follow grouped", items[1].Group, items[2].Group)
+	}
+
+	if unread, _ := activity.UnreadCount(ctx); unread != 3 {
+		t.Errorf("unread count got %d want one per group %d", unread, 3)
+	}
+
+	// trimmed groups take their state with them
+	for i := 0; i < 3; i++ {
+		activity.Store(ctx, "later", at.Add(time.Hour).Unix())
+	}
+
+	if keys, _ := server.HKeys("notifications:okandas.groups"); len(keys) != 0 {
+		t.Errorf("trimming left groups behind %v", keys)
+	}
+
+}
+
+func TestActivityRanking(t *testing.T) {
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	feed := NewFeed("home", 5, 10, NewRedisClient(c))
+	feed.Scorer = Weighted{Scorer: Decay{HalfLife: time.Hour}, Weights: map[string]float64{"mention": 4}}
+
+	activity := NewActivity("okandas", feed)
+
+	now := time.Unix(time.Now().Unix(), 0)
+
+	activity.StoreAction(ctx, Action{Value: "rudo mentioned you", At: now.Add(-3 * time.Hour), Method: "mention"})
+	activity.StoreAction(ctx, Action{Value: "farai liked your photo", At: now.Add(-2 * time.Hour), Method: "like"})
+	activity.StoreAction(ctx, Action{Value: "tendai liked your photo", At: now.Add(-30 * time.Minute), Method: "like"})
+
+	items, err := activity.Peek(ctx, 1, 10)
+
+	if err != nil {
+		t.Errorf("peek error %s", err)
+		return
+	}
+
+	want := []string{"tendai liked your photo", "rudo mentioned you", "farai liked your photo"}
+
+	if got := actionValues(items); !cmp.Equal(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// scored when stored, a moment after now
+	if math.Abs(items[1].Score-0.5) > 1e-3 || !items[1].At.Equal(now.Add(-3*time.Hour)) {
+		t.Errorf("got %+v want the mention scored 0.5 at the time it happened", items[1])
+	}
+
+	rescored, err := activity.Rescore(ctx, now.Add(2*time.Hour))
+
+	if err != nil || rescored != 3 {
+		t.Errorf("rescore got %d, %v want %d", rescored, err, 3)
+	}
+
+	items, _ = activity.Peek(ctx, 1, 10)
+
+	if math.Abs(items[1].Score-0.125) > 1e-6 {
+		t.Errorf("got the mention scored %v after two hours want %v", items[1].Score, 0.125)
+	}
+
+	// rescoring without a scorer puts the feed back in the order of time
+	feed.Scorer = nil
+
+	activity.Rescore(ctx, now)
+
+	items, _ = activity.Peek(ctx, 1, 10)
+
+	want = []string{"tendai liked your photo", "farai liked your photo", "rudo mentioned you"}
+
+	if got := actionValues(items); !cmp.Equal(got, want) || items[2].Score != float64(items[2].At.Unix()) {
+		t.Errorf("got %v scored %v want %v by time", got, items[2].Score, want)
+	}
+
+	if keys, _ := server.HKeys("home:okandas.times"); len(keys) != 0 {
+		t.Errorf("rescoring by time left times behind %v", keys)
+	}
+
+}
+
+// actionValues returns the values of the actions of items
+func actionValues(items []Item) []string {
+	values := make([]string, len(items))
+	for i, item := range items {
+		action, _ := item.Action()
+		values[i] = action.Value
+	}
+	return values
+}
+
+func TestActivityPins(t *testing.T) {
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	activity := NewActivity("okandas", NewFeed("notifications", 3, 10, NewRedisClient(c)))
+
+	activity.Store(ctx, "verify your email", 1700000000)
+	activity.Store(ctx, "your password changed", 1700000001)
+
+	items, _ := activity.Peek(ctx, 1, 10)
+
+	if pinned, err := activity.Pin(ctx, items[1].ID, time.Time{}); err != nil || !pinned {
+		t.Errorf("pin got %t, %v want the notice pinned", pinned, err)
+	}
+
+	activity.SetPriority(ctx, items[0].ID, 1)
+
+	for i := 0; i < 5; i++ {
+		activity.Store(ctx, "farai liked your photo "+strconv.Itoa(i), int64(1700000010+i))
+	}
+
+	items, _ = activity.Peek(ctx, 1, 2)
+
+	if got := itemValues(items); !cmp.Equal(got, []string{"verify your email", "farai liked your photo 4"}) || !items[0].Pinned {
+		t.Errorf("got %v want the pinned notice first", got)
+	}
+
+	all, _ := activity.All(ctx)
+
+	if got := itemValues(all); len(got) != 4 || got[3] != "your password changed" || all[3].Priority != 1 {
+		t.Errorf("got %v want the pinned notice, the two latest likes and the priority notice", got)
+	}
+
+	// a pin that expired lets the notice go
+	activity.Pin(ctx, items[0].ID, time.Unix(1700000000, 0))
+	activity.Store(ctx, "rudo followed you", 1700000020)
+
+	if all, _ = activity.All(ctx); len(all) != 3 || all[2].Value != "your password changed" {
+		t.Errorf("got %v want the expired pin trimmed", itemValues(all))
+	}
+
+	if keys, _ := server.HKeys("notifications:okandas.pins"); len(keys) != 0 {
+		t.Errorf("trimming left pins behind %v", keys)
+	}
+
+}
+
+func TestActivityNotSupported(t *testing.T) {
+
+	ctx := context.Background()
+
+	feed := NewFeed("news", 5, 2, basic{NewMemoryBackend()})
+	feed.GroupWindow = time.Hour
+	activity := NewActivity("okandas", feed)
+
+	if _, err := activity.Store(ctx, "first", 1); err != nil {
+		t.Errorf("store error %s", err)
+	}
+
+	tt := []struct {
+		op   string
+		call func() error
+	}{
+		{"store", func() error {
+			_, err := activity.StoreAction(ctx, Action{Value: "liked", Method: "like", At: time.Unix(2, 0)})
+			return err
+		}},
+		{"update", func() error { _, err := activity.Update(ctx, "1", "edited"); return err }},
+		{"move", func() error { _, err := activity.Move(ctx, "1", 3); return err }},
+		{"mark read", func() error { _, err := activity.MarkRead(ctx, "1"); return err }},
+		{"mark unread", func() error { _, err := activity.MarkUnread(ctx, "1"); return err }},
+		{"mark read", func() error { _, err := activity.MarkAllReadUpTo(ctx, "1"); return err }},
+		{"rescore", func() error { _, err := activity.Rescore(ctx, time.Now()); return err }},
+		{"pin", func() error { _, err := activity.Pin(ctx, "1", time.Time{}); return err }},
+		{"unpin", func() error { _, err := activity.Unpin(ctx, "1"); return err }},
+		{"set priority", func() error { _, err := activity.SetPriority(ctx, "1", 1); return err }},
+	}
+
+	for _, tc := range tt {
+		err := tc.call()
+
+		var opErr *OpError
+		if !errors.Is(err, ErrNotSupported) || !errors.As(err, &opErr) || opErr.Op != tc.op {
+			t.Errorf("%s got %v want %v", tc.op, err, ErrNotSupported)
+		}
+	}
+
+}
+
+// basic is a backend with only the methods of the Backend interface
+type basic struct {
+	Backend
+}
+
+func (b basic) WithFeed(name string, size int) Backend {
+	return basic{b.Backend.WithFeed(name, size)}
+}
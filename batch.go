@@ -0,0 +1,215 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Result is the outcome of a batch operation for one user
+type Result struct {
+	// Value is the change, count or unread count returned by the operation
+	Value int64
+	// Events is the page returned by Peek and Read
+	Events []Item
+	Err    error
+}
+
+// BatchBackend is implemented by backends that can run an operation for many users in a
+// single round trip. BatchActivity falls back to one call per user for other backends
+type BatchBackend interface {
+	StoreBatch(ctx context.Context, users []string, id, value string, at int64, score float64) map[string]Result
+	PaginateBatch(ctx context.Context, users []string, page, perPage int) map[string]Result
+	CountBatch(ctx context.Context, users []string) map[string]Result
+	UnreadCountBatch(ctx context.Context, users []string) map[string]Result
+	ResetLastReadBatch(ctx context.Context, users []string, at int64) map[string]Result
+	WipeBatch(ctx context.Context, users []string) map[string]Result
+}
+
+// BatchActivity is the activity of many users in a feed, every operation runs for each
+// user and returns a Result per user ID
+type BatchActivity struct {
+	UserIDs []string `json:"user_ids"`
+	Feed    *Feed    `json:"feed"`
+}
+
+// backend returns the feeds backend scoped to the feed, see Activity.backend
+func (b BatchActivity) backend() Backend {
+	return b.Feed.P.WithFeed(b.Feed.Name, b.Feed.Size)
+}
+
+// batch returns the backend when it runs batches itself
+func (b BatchActivity) batch() (BatchBackend, bool) {
+	batch, ok := b.backend().(BatchBackend)
+	return batch, ok
+}
+
+// users returns the user IDs without duplicates so every user is only written once
+func (b BatchActivity) users() []string {
+	seen := make(map[string]bool, len(b.UserIDs))
+	users := make([]string, 0, len(b.UserIDs))
+
+	for _, user := range b.UserIDs {
+		if seen[user] {
+			continue
+		}
+		seen[user] = true
+		users = append(users, user)
+	}
+
+	return users
+}
+
+// each runs fn for every user, it is used for backends that do not run batches
+func (b BatchActivity) each(fn func(user string) Result) map[string]Result {
+	results := make(map[string]Result, len(b.UserIDs))
+
+	for _, user := range b.users() {
+		results[user] = fn(user)
+	}
+
+	return results
+}
+
+// Store stores an event for every user, the event has the same ID and score in every users feed
+func (b BatchActivity) Store(ctx context.Context, value string, at int64) map[string]Result {
+	id := b.Feed.newID(value, at)
+	score := b.Feed.scoreItem(Item{Value: value, At: time.Unix(at, 0)}, time.Now())
+
+	if batch, ok := b.batch(); ok {
+		return batch.StoreBatch(ctx, b.users(), id, value, at, score)
+	}
+
+	backend := b.backend()
+
+	return b.each(func(user string) Result {
+		change, err := backend.StoreID(ctx, user, id, value, at, score)
+		return Result{Value: change, Err: err}
+	})
+}
+
+// Count returns the total count of events of every user
+func (b BatchActivity) Count(ctx context.Context) map[string]Result {
+	if batch, ok := b.batch(); ok {
+		return batch.CountBatch(ctx, b.users())
+	}
+
+	backend := b.backend()
+
+	return b.each(func(user string) Result {
+		count, err := backend.Count(ctx, user)
+		return Result{Value: int64(count), Err: err}
+	})
+}
+
+// UnreadCount reads the unread count of each user, see Activity.UnreadCount
+func (b BatchActivity) UnreadCount(ctx context.Context) map[string]Result {
+	if batch, ok := b.batch(); ok {
+		return batch.UnreadCountBatch(ctx, b.users())
+	}
+
+	backend := b.backend()
+
+	return b.each(func(user string) Result {
+		count, err := backend.UnreadCount(ctx, user)
+
+		if errors.Is(err, ErrUserNotFound) {
+			return Result{}
+		}
+
+		return Result{Value: count, Err: err}
+	})
+}
+
+// Peek returns a page of events for every user, newest first, without marking the feed as read
+func (b BatchActivity) Peek(ctx context.Context, page, perPage int) map[string]Result {
+	if batch, ok := b.batch(); ok {
+		return batch.PaginateBatch(ctx, b.users(), page, perPage)
+	}
+
+	backend := b.backend()
+
+	return b.each(func(user string) Result {
+		events, err := backend.Paginate(ctx, user, page, perPage)
+		return Result{Events: events, Err: err}
+	})
+}
+
+// Read returns a page of events for every user, newest first, and marks the feed as read
+// for the users whose page was read
+func (b BatchActivity) Read(ctx context.Context, page, perPage int) map[string]Result {
+	results := b.Peek(ctx, page, perPage)
+
+	var read []string
+	for user, result := range results {
+		if result.Err == nil {
+			read = append(read, user)
+		}
+	}
+
+	if len(read) == 0 {
+		return results
+	}
+
+	reset := BatchActivity{UserIDs: read, Feed: b.Feed}.resetLastRead(ctx, time.Now().Unix())
+
+	for user, result := range reset {
+		if result.Err != nil {
+			results[user] = Result{Err: result.Err}
+		}
+	}
+
+	return results
+}
+
+// Paginate returns a page of events for every user and marks the feed as read for the users
+// whose page was read
+//
+// Deprecated: use Read, or Peek to leave the feeds unread
+func (b BatchActivity) Paginate(ctx context.Context, page, perPage int) map[string]Result {
+	return b.Read(ctx, page, perPage)
+}
+
+// resetLastRead sets the last read time stamp of every user
+func (b BatchActivity) resetLastRead(ctx context.Context, at int64) map[string]Result {
+	if batch, ok := b.batch(); ok {
+		return batch.ResetLastReadBatch(ctx, b.users(), at)
+	}
+
+	backend := b.backend()
+
+	return b.each(func(user string) Result {
+		_, err := backend.ResetLastRead(ctx, user, at)
+		return Result{Err: err}
+	})
+}
+
+// Rescore scores the events of every user anew at now, see Activity.Rescore
+func (b BatchActivity) Rescore(ctx context.Context, now time.Time) map[string]Result {
+	return b.each(func(user string) Result {
+		rescored, err := Activity{UserID: user, Feed: b.Feed}.Rescore(ctx, now)
+		return Result{Value: rescored, Err: err}
+	})
+}
+
+// Wipe removes all events of every user
+func (b BatchActivity) Wipe(ctx context.Context) map[string]Result {
+	if batch, ok := b.batch(); ok {
+		return batch.WipeBatch(ctx, b.users())
+	}
+
+	backend := b.backend()
+
+	return b.each(func(user string) Result {
+		removed, err := backend.Wipe(ctx, user)
+		return Result{Value: removed, Err: err}
+	})
+}
+
+// NewBatchActivity instantiates the activity of many users in a feed
+func NewBatchActivity(ids []string, feed *Feed) *BatchActivity {
+	return &BatchActivity{
+		UserIDs: ids,
+		Feed:    feed,
+	}
+}
@@ -0,0 +1,159 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBatchActivity(t *testing.T) {
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	tt := []struct {
+		name    string
+		backend Backend
+	}{
+		{"redis", NewRedisClient(c)},
+		{"memory", NewMemoryBackend()},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			ctx := context.Background()
+
+			feed := NewFeed("notifications", 3, 2, tc.backend)
+			users := []string{"okandas", "tendai", "rudo", "okandas"}
+			batch := NewBatchActivity(users, feed)
+
+			at := time.Now().Unix()
+
+			for i, value := range []string{"a", "b", "c", "d"} {
+				results := batch.Store(ctx, value, at+int64(i))
+
+				if len(results) != 3 {
+					t.Errorf("got %d results want %d", len(results), 3)
+				}
+
+				for user, result := range results {
+					if result.Err != nil {
+						t.Errorf("store for %s error %s", user, result.Err)
+					}
+				}
+			}
+
+			for user, result := range batch.Count(ctx) {
+				if result.Err != nil || result.Value != 3 {
+					t.Errorf("count for %s got %d %v want %d", user, result.Value, result.Err, 3)
+				}
+			}
+
+			for user, result := range batch.UnreadCount(ctx) {
+				if result.Err != nil || result.Value != 3 {
+					t.Errorf("unread for %s got %d %v want %d", user, result.Value, result.Err, 3)
+				}
+			}
+
+			for user, result := range batch.Read(ctx, 1, 2) {
+				if result.Err != nil {
+					t.Errorf("paginate for %s error %s", user, result.Err)
+				}
+				if want := []string{"d", "c"}; !cmp.Equal(itemValues(result.Events), want) {
+					t.Errorf("paginate for %s got %v want %v", user, itemValues(result.Events), want)
+				}
+			}
+
+			for _, user := range batch.UserIDs {
+				if _, err := feed.P.WithFeed(feed.Name, feed.Size).LastRead(ctx, user); err != nil {
+					t.Errorf("paginate did not reset last read for %s %s", user, err)
+				}
+			}
+
+			for user, result := range batch.Peek(ctx, 0, 2) {
+				if !errors.Is(result.Err, ErrInvalidPage) {
+					t.Errorf("paginate for %s got %v want %v", user, result.Err, ErrInvalidPage)
+				}
+			}
+
+			for user, result := range batch.Paginate(ctx, 1, 1) {
+				if want := []string{"d"}; result.Err != nil || !cmp.Equal(itemValues(result.Events), want) {
+					t.Errorf("paginate for %s got %v %v want %v", user, itemValues(result.Events), result.Err, want)
+				}
+			}
+
+			for user, result := range batch.Wipe(ctx) {
+				if result.Err != nil || result.Value != 3 {
+					t.Errorf("wipe for %s got %d %v want %d", user, result.Value, result.Err, 3)
+				}
+			}
+
+			for user, result := range batch.Count(ctx) {
+				if !errors.Is(result.Err, ErrUserNotFound) {
+					t.Errorf("count for %s after wipe got %v want %v", user, result.Err, ErrUserNotFound)
+				}
+
+				var opErr *OpError
+				if !errors.As(result.Err, &opErr) || opErr.User != user || opErr.Feed != "notifications" {
+					t.Errorf("count for %s error does not name the user and feed %v", user, result.Err)
+				}
+			}
+
+		})
+	}
+
+}
+
+func TestBatchActivityUnavailable(t *testing.T) {
+
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	feed := NewFeed("notifications", 3, 2, NewRedisClient(c))
+	batch := NewBatchActivity([]string{"okandas", "tendai"}, feed)
+
+	server.Close()
+
+	results := batch.Store(ctx, "a", time.Now().Unix())
+
+	if len(results) != 2 {
+		t.Errorf("got %d results want %d", len(results), 2)
+	}
+
+	for user, result := range results {
+		if !errors.Is(result.Err, ErrBackendUnavailable) {
+			t.Errorf("store for %s got %v want %v", user, result.Err, ErrBackendUnavailable)
+		}
+	}
+
+	// batches report the operation under the name of the single user call
+	var opErr *OpError
+
+	for user, result := range batch.UnreadCount(ctx) {
+		if !errors.As(result.Err, &opErr) || opErr.Op != "unread count" {
+			t.Errorf("unread count for %s got %v want an OpError of %q", user, result.Err, "unread count")
+		}
+	}
+
+}
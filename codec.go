@@ -0,0 +1,160 @@
+package feeder
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Codec encodes the actions stored in a feed. Every payload starts with the version byte of
+// the codec that encoded it, so payloads keep decoding after a feed switches codecs
+type Codec interface {
+	// Name is the name the codec is configured by
+	Name() string
+	// Version is the byte payloads encoded by the codec start with
+	Version() byte
+	Encode(action Action) ([]byte, error)
+	Decode(data []byte) (Action, error)
+}
+
+// The versions of the codecs shipped with feeder. Versions are below the printable
+// characters so that values stored as plain text are never taken for a payload
+const (
+	JSONVersion        byte = 0x01
+	GobVersion         byte = 0x02
+	MessagePackVersion byte = 0x03
+)
+
+var (
+	// JSON encodes actions as JSON, it is the codec of feeds that do not set one
+	JSON Codec = jsonCodec{}
+	// Gob encodes actions with encoding/gob
+	Gob Codec = gobCodec{}
+	// MessagePack encodes actions as MessagePack maps
+	MessagePack Codec = msgpackCodec{}
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{}
+	versions = map[byte]Codec{}
+)
+
+func init() {
+	RegisterCodec(JSON)
+	RegisterCodec(Gob)
+	RegisterCodec(MessagePack)
+}
+
+// RegisterCodec makes a codec available under its name and version. Like RegisterDriver
+// it panics when the codec is nil or its name or version is registered twice
+func RegisterCodec(codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	if codec == nil {
+		panic("feeder: RegisterCodec codec is nil")
+	}
+	if _, dup := codecs[codec.Name()]; dup {
+		panic("feeder: RegisterCodec called twice for codec " + codec.Name())
+	}
+	if _, dup := versions[codec.Version()]; dup {
+		panic(fmt.Sprintf("feeder: RegisterCodec called twice for version %#x", codec.Version()))
+	}
+
+	codecs[codec.Name()] = codec
+	versions[codec.Version()] = codec
+}
+
+// Codecs returns the names of the registered codecs in order
+func Codecs() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// LookupCodec returns the codec registered under name
+func LookupCodec(name string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	codec, ok := codecs[name]
+	return codec, ok
+}
+
+// EncodeAction encodes an action with codec into a payload starting with the codecs version
+func EncodeAction(codec Codec, action Action) (string, error) {
+	data, err := codec.Encode(action)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", ErrInvalidPayload, codec.Name(), err)
+	}
+
+	return string(codec.Version()) + string(data), nil
+}
+
+// DecodeAction decodes a payload with the codec of its version byte. Values that were stored
+// as plain text decode to an action holding the value
+func DecodeAction(payload string) (Action, error) {
+	if payload == "" {
+		return Action{}, nil
+	}
+
+	codecsMu.RLock()
+	codec, ok := versions[payload[0]]
+	codecsMu.RUnlock()
+
+	if !ok {
+		return Action{Value: payload}, nil
+	}
+
+	action, err := codec.Decode([]byte(payload[1:]))
+	if err != nil {
+		return Action{}, fmt.Errorf("%w: %s: %w", ErrInvalidPayload, codec.Name(), err)
+	}
+
+	return action, nil
+}
+
+// jsonCodec is the JSON codec
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string  { return "json" }
+func (jsonCodec) Version() byte { return JSONVersion }
+
+func (jsonCodec) Encode(action Action) ([]byte, error) {
+	return json.Marshal(action)
+}
+
+func (jsonCodec) Decode(data []byte) (Action, error) {
+	var action Action
+	err := json.Unmarshal(data, &action)
+	return action, err
+}
+
+// gobCodec is the gob codec
+type gobCodec struct{}
+
+func (gobCodec) Name() string  { return "gob" }
+func (gobCodec) Version() byte { return GobVersion }
+
+func (gobCodec) Encode(action Action) ([]byte, error) {
+	var b bytes.Buffer
+	err := gob.NewEncoder(&b).Encode(action)
+	return b.Bytes(), err
+}
+
+func (gobCodec) Decode(data []byte) (Action, error) {
+	var action Action
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&action)
+	return action, err
+}
@@ -0,0 +1,118 @@
+package feeder
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCodecs(t *testing.T) {
+
+	action := Action{
+		Value:  "okandas liked your photo",
+		At:     time.Unix(1700000000, 250),
+		Method: "like",
+		Actor:  "okandas",
+		Object: "photo:42",
+		Meta:   map[string]string{"photo": "42", "album": "holidays"},
+	}
+
+	tt := []struct {
+		codec   Codec
+		version byte
+	}{
+		{codec: JSON, version: JSONVersion},
+		{codec: Gob, version: GobVersion},
+		{codec: MessagePack, version: MessagePackVersion},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.codec.Name(), func(t *testing.T) {
+
+			payload, err := EncodeAction(tc.codec, action)
+
+			if err != nil {
+				t.Errorf("encode error %s", err)
+				return
+			}
+
+			if payload[0] != tc.version {
+				t.Errorf("payload starts with %#x want %#x", payload[0], tc.version)
+			}
+
+			got, err := DecodeAction(payload)
+
+			if err != nil {
+				t.Errorf("decode error %s", err)
+				return
+			}
+
+			if !cmp.Equal(got, action) {
+				t.Errorf("got %+v want %+v", got, action)
+			}
+
+			if _, err := DecodeAction(payload[:len(payload)/2]); !errors.Is(err, ErrInvalidPayload) {
+				t.Errorf("decoding a truncated payload got %v want %v", err, ErrInvalidPayload)
+			}
+
+		})
+	}
+
+	// values stored before codecs decode to their value
+	got, err := DecodeAction("John liked Robert")
+
+	if err != nil || got.Value != "John liked Robert" {
+		t.Errorf("plain value got %+v %v", got, err)
+	}
+
+}
+
+func TestMessagePack(t *testing.T) {
+
+	action := Action{Value: "a", At: time.Unix(1, 0), Method: "m"}
+
+	want := []byte{
+		0x84,
+		0xa5, 'v', 'a', 'l', 'u', 'e', 0xa1, 'a',
+		0xa2, 'a', 't', 0xc7, 12, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1,
+		0xa6, 'm', 'e', 't', 'h', 'o', 'd', 0xa1, 'm',
+		0xa4, 'm', 'e', 't', 'a', 0xc0,
+	}
+
+	got, _ := MessagePack.Encode(action)
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("got % x want % x", got, want)
+	}
+
+	// other writers may use the 32 and 64 bit timestamps and add keys
+	data := []byte{
+		0x84,
+		0xa5, 'v', 'a', 'l', 'u', 'e', 0xa1, 'a',
+		0xa2, 'a', 't', 0xd7, 0xff, 0, 0, 0, 4, 0, 0, 0, 1,
+		0xa5, 'e', 'x', 't', 'r', 'a', 0x92, 0xcd, 1, 0, 0x81, 0xa1, 'k', 0xc3,
+		0xa6, 'm', 'e', 't', 'h', 'o', 'd', 0xa1, 'm',
+	}
+
+	decoded, err := MessagePack.Decode(data)
+
+	if err != nil {
+		t.Errorf("decode error %s", err)
+		return
+	}
+
+	if !decoded.At.Equal(time.Unix(1, 1)) || decoded.Value != "a" || decoded.Method != "m" {
+		t.Errorf("got %+v want %+v", decoded, Action{Value: "a", At: time.Unix(1, 1), Method: "m"})
+	}
+
+	// lengths are read from the payload, a map claiming more entries than it holds is invalid
+	oversized := []byte{MessagePackVersion, 0x81, 0xa4, 'm', 'e', 't', 'a', 0xdf, 0x7f, 0xff, 0xff, 0xff}
+
+	if _, err := DecodeAction(string(oversized)); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("decoding an oversized map got %v want %v", err, ErrInvalidPayload)
+	}
+
+}
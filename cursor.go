@@ -0,0 +1,118 @@
+package feeder
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Cursor is a position in a users feed, the score and ID of the event at it.
+// Events with the same score are ordered by ID, so a cursor stays put when new events arrive
+type Cursor struct {
+	Score float64
+	ID    string
+}
+
+// IsZero reports whether the cursor is the position before the newest event
+func (c Cursor) IsZero() bool {
+	return c == Cursor{}
+}
+
+// older reports whether the event scored score with id comes after the cursor, newest first
+func (c Cursor) older(score float64, id string) bool {
+	return score < c.Score || (score == c.Score && id < c.ID)
+}
+
+// newer reports whether the event scored score with id comes before the cursor, newest first
+func (c Cursor) newer(score float64, id string) bool {
+	return score > c.Score || (score == c.Score && id > c.ID)
+}
+
+// Cursor returns the position of the item in its feed
+func (i Item) Cursor() Cursor {
+	return Cursor{Score: i.Score, ID: i.ID}
+}
+
+// Page is a page of items read with a cursor, newest first
+type Page struct {
+	Items []Item `json:"items"`
+	// Next continues after the oldest item of the page and Prev before its newest,
+	// both hold the cursor the page was read with when it is empty
+	Next string `json:"next"`
+	Prev string `json:"prev"`
+}
+
+// cursorMACSize is the length of the signature of an encoded cursor
+const cursorMACSize = 16
+
+// processCursorKey signs the cursors of feeds without a CursorKey
+var processCursorKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("feeder: cursor key: %s", err))
+	}
+	return key
+}()
+
+// cursorKey returns the key the cursors of a feed are signed with
+func cursorKey(f *Feed) []byte {
+	if len(f.CursorKey) > 0 {
+		return f.CursorKey
+	}
+	return processCursorKey
+}
+
+// cursorMAC signs a cursor for the feed and user it was handed out for
+func cursorMAC(key []byte, feed, user string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(feed))
+	mac.Write([]byte{0})
+	mac.Write([]byte(user))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)[:cursorMACSize]
+}
+
+// encodeCursor encodes a cursor into an opaque token signed for a feed and user,
+// the zero cursor encodes to the empty token
+func encodeCursor(key []byte, feed, user string, c Cursor) string {
+	if c.IsZero() {
+		return ""
+	}
+
+	payload := binary.BigEndian.AppendUint64(nil, math.Float64bits(c.Score))
+	payload = append(payload, c.ID...)
+
+	token := append(cursorMAC(key, feed, user, payload), payload...)
+
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+// decodeCursor decodes a token made by encodeCursor for the same feed and user,
+// the empty token decodes to the zero cursor
+func decodeCursor(key []byte, feed, user, token string) (Cursor, error) {
+	if token == "" {
+		return Cursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+
+	if err != nil || len(raw) < cursorMACSize+8 {
+		return Cursor{}, fmt.Errorf("%w: malformed", ErrInvalidCursor)
+	}
+
+	sum, payload := raw[:cursorMACSize], raw[cursorMACSize:]
+
+	if !hmac.Equal(sum, cursorMAC(key, feed, user, payload)) {
+		return Cursor{}, fmt.Errorf("%w: signature mismatch", ErrInvalidCursor)
+	}
+
+	return Cursor{
+		Score: math.Float64frombits(binary.BigEndian.Uint64(payload[:8])),
+		ID:    string(payload[8:]),
+	}, nil
+}
@@ -0,0 +1,57 @@
+package feeder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCursorEncoding(t *testing.T) {
+
+	key := []byte("secret")
+	cursor := Cursor{Score: 1700000000, ID: "okandas liked your photo"}
+
+	token := encodeCursor(key, "notifications", "okandas", cursor)
+
+	got, err := decodeCursor(key, "notifications", "okandas", token)
+
+	if err != nil {
+		t.Errorf("decode error %s", err)
+	}
+
+	if got != cursor {
+		t.Errorf("got %+v want %+v", got, cursor)
+	}
+
+	tampered := []byte(token)
+	tampered[len(tampered)-1] ^= 1
+
+	tt := []struct {
+		description string
+		key         string
+		feed        string
+		user        string
+		token       string
+	}{
+		{description: "altered", key: "secret", feed: "notifications", user: "okandas", token: string(tampered)},
+		{description: "other user", key: "secret", feed: "notifications", user: "tendai", token: token},
+		{description: "other feed", key: "secret", feed: "news", user: "okandas", token: token},
+		{description: "other key", key: "another secret", feed: "notifications", user: "okandas", token: token},
+		{description: "malformed", key: "secret", feed: "notifications", user: "okandas", token: "not a cursor"},
+	}
+
+	for _, tc := range tt {
+
+		t.Run(tc.description, func(t *testing.T) {
+
+			if _, err := decodeCursor([]byte(tc.key), tc.feed, tc.user, tc.token); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("got %v want %v", err, ErrInvalidCursor)
+			}
+
+		})
+	}
+
+	if got, err := decodeCursor(key, "notifications", "okandas", ""); err != nil || !got.IsZero() {
+		t.Errorf("empty cursor got %+v %v want the zero cursor", got, err)
+	}
+
+}
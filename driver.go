@@ -0,0 +1,87 @@
+package feeder
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Driver opens backends for an engine, drivers make themselves available with RegisterDriver
+type Driver interface {
+	Open(config Config) (Backend, error)
+}
+
+// DriverFunc adapts a function to the Driver interface
+type DriverFunc func(config Config) (Backend, error)
+
+// Open calls f(config)
+func (f DriverFunc) Open(config Config) (Backend, error) {
+	return f(config)
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{}
+)
+
+// RegisterDriver makes a driver available under the engine name. Like database/sql it
+// panics when the driver is nil or the name is registered twice, so call it from init
+func RegisterDriver(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if driver == nil {
+		panic("feeder: RegisterDriver driver is nil")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("feeder: RegisterDriver called twice for driver " + name)
+	}
+
+	drivers[name] = driver
+}
+
+// Drivers returns the names of the registered drivers in order
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// registered reports whether a driver is registered for engine
+func registered(engine string) bool {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	_, ok := drivers[engine]
+	return ok
+}
+
+// Open opens a backend with the driver registered for config.Engine,
+// an empty engine opens the default redis driver
+func Open(config Config) (Backend, error) {
+	if config.Engine == "" {
+		config.Engine = "redis"
+	}
+
+	driversMu.RLock()
+	driver, ok := drivers[config.Engine]
+	driversMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w %q (forgotten import?)", ErrUnknownEngine, config.Engine)
+	}
+
+	backend, err := driver.Open(config)
+	if err != nil {
+		return nil, fmt.Errorf("feeder: open %s: %w", config.Engine, err)
+	}
+
+	return backend, nil
+}
@@ -0,0 +1,101 @@
+package feeder
+
+import (
+	"errors"
+	"testing"
+)
+
+// opened is the config the test driver was last opened with
+var opened Config
+
+// drivers are registered once like database/sql drivers so the tests can run repeatedly
+func init() {
+	RegisterDriver("test", DriverFunc(func(config Config) (Backend, error) {
+		opened = config
+		return NewMemoryBackend(), nil
+	}))
+}
+
+func TestOpen(t *testing.T) {
+
+	opened = Config{}
+
+	tt := []struct {
+		description string
+		config      Config
+		want        error
+	}{
+		{description: "opens a registered driver", config: Config{Engine: "test", Host: "db.internal"}, want: nil},
+		{description: "opens the built in memory driver", config: Config{Engine: "memory"}, want: nil},
+		{description: "opens redis by default", config: Config{Host: "localhost", Port: "6379"}, want: nil},
+		{description: "fails on an unknown engine", config: Config{Engine: "cassandra"}, want: ErrUnknownEngine},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			backend, err := Open(tc.config)
+
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got %v want %v", err, tc.want)
+				return
+			}
+
+			if err == nil && backend == nil {
+				t.Errorf("opened a nil backend")
+			}
+
+		})
+	}
+
+	if opened.Host != "db.internal" {
+		t.Errorf("driver was opened with %+v", opened)
+	}
+
+	if _, ok := mustOpen(t, Config{}).(Redis); !ok {
+		t.Errorf("an empty engine should open redis")
+	}
+
+}
+
+func TestRegisterDriver(t *testing.T) {
+
+	tt := []struct {
+		description string
+		name        string
+		driver      Driver
+	}{
+		{description: "panics on a nil driver", name: "nil", driver: nil},
+		{description: "panics on a duplicate driver", name: "redis", driver: DriverFunc(openRedis)},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected RegisterDriver to panic")
+				}
+			}()
+
+			RegisterDriver(tc.name, tc.driver)
+
+		})
+	}
+
+	drivers := Drivers()
+
+	if len(drivers) < 2 || drivers[0] > drivers[1] {
+		t.Errorf("got drivers %v want the built in drivers in order", drivers)
+	}
+
+}
+
+// mustOpen opens a backend or fails the test
+func mustOpen(t *testing.T, config Config) Backend {
+	backend, err := Open(config)
+	if err != nil {
+		t.Fatalf("open error %s", err)
+	}
+	return backend
+}
@@ -0,0 +1,225 @@
+package feeder
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// LoadConfig builds a Config from its default tags, then the environment and then the
+// command line flags in args, and checks that every required setting is set.
+//
+// Settings are named after the fields of Config, the environment variables in upper snake
+// case after prefix (FEEDER_READ_TIMEOUT for the prefix FEEDER) and the flags in kebab case
+// (-read-timeout). When args asks for help the error is flag.ErrHelp, print ConfigUsage then
+func LoadConfig(prefix string, args []string) (Config, error) {
+	var config Config
+
+	v := reflect.ValueOf(&config).Elem()
+	fields := configFields()
+
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+		if err := setField(v.Field(f.index), f.def); err != nil {
+			return config, fmt.Errorf("%w: default of %s: %w", ErrInvalidConfig, f.name, err)
+		}
+	}
+
+	for _, f := range fields {
+		value, ok := os.LookupEnv(f.env(prefix))
+		if !ok {
+			continue
+		}
+		if err := setField(v.Field(f.index), value); err != nil {
+			return config, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, f.env(prefix), err)
+		}
+	}
+
+	flags := flag.NewFlagSet("feeder", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+
+	for _, f := range fields {
+		flags.Var(fieldValue{v.Field(f.index)}, f.flag(), f.desc)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return config, err
+		}
+		return config, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	var missing []string
+	for _, f := range fields {
+		if f.required && v.Field(f.index).IsZero() {
+			missing = append(missing, "-"+f.flag()+" ("+f.env(prefix)+")")
+		}
+	}
+
+	if len(missing) > 0 {
+		return config, fmt.Errorf("%w: missing required %s", ErrInvalidConfig, strings.Join(missing, ", "))
+	}
+
+	return config, nil
+}
+
+// ConfigUsage describes every setting LoadConfig reads, in the style of flag.PrintDefaults
+func ConfigUsage(prefix string) string {
+	var b strings.Builder
+
+	for _, f := range configFields() {
+		fmt.Fprintf(&b, "  -%s %s\n    \t", f.flag(), f.kind)
+
+		if f.desc != "" {
+			b.WriteString(f.desc + " ")
+		}
+
+		details := []string{"env " + f.env(prefix)}
+		if f.def != "" {
+			details = append(details, fmt.Sprintf("default %q", f.def))
+		}
+		if f.required {
+			details = append(details, "required")
+		}
+
+		fmt.Fprintf(&b, "(%s)\n", strings.Join(details, ", "))
+	}
+
+	return b.String()
+}
+
+// configField is a setting read from the tags of a Config field
+type configField struct {
+	index    int
+	name     string
+	kind     string
+	def      string
+	desc     string
+	required bool
+}
+
+// env is the name of the environment variable of the setting
+func (f configField) env(prefix string) string {
+	name := strings.ToUpper(strings.Join(words(f.name), "_"))
+	if prefix == "" {
+		return name
+	}
+	return strings.ToUpper(prefix) + "_" + name
+}
+
+// flag is the name of the command line flag of the setting
+func (f configField) flag() string {
+	return strings.ToLower(strings.Join(words(f.name), "-"))
+}
+
+// configFields lists the settings of Config in the order they are declared
+func configFields() []configField {
+	t := reflect.TypeOf(Config{})
+	fields := make([]configField, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		kind := field.Type.Kind().String()
+		if field.Type == reflect.TypeOf(time.Duration(0)) {
+			kind = "duration"
+		}
+
+		fields = append(fields, configField{
+			index:    i,
+			name:     field.Name,
+			kind:     kind,
+			def:      field.Tag.Get("default"),
+			desc:     field.Tag.Get("desc"),
+			required: field.Tag.Get("required") == "true",
+		})
+	}
+
+	return fields
+}
+
+// setField parses value into a string, int, bool or duration field
+func setField(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+
+	return nil
+}
+
+// fieldValue lets the flag package set a Config field
+type fieldValue struct {
+	field reflect.Value
+}
+
+func (v fieldValue) String() string {
+	if !v.field.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(v.field.Interface())
+}
+
+func (v fieldValue) Set(value string) error {
+	return setField(v.field, value)
+}
+
+// IsBoolFlag lets boolean flags be given without a value
+func (v fieldValue) IsBoolFlag() bool {
+	return v.field.IsValid() && v.field.Kind() == reflect.Bool
+}
+
+// words splits a Go identifier into its words, keeping initialisms together
+//
+//	ReadTimeout -> Read Timeout, TLS -> TLS, URL -> URL
+func words(name string) []string {
+	runes := []rune(name)
+
+	var parts []string
+	start := 0
+
+	for i := 1; i < len(runes); i++ {
+		lowerBefore := unicode.IsLower(runes[i-1])
+		lowerAfter := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+		if unicode.IsUpper(runes[i]) && (lowerBefore || lowerAfter) {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	return append(parts, string(runes[start:]))
+}
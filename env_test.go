@@ -0,0 +1,93 @@
+package feeder
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+
+	t.Setenv("FEEDER_HOST", "redis.internal")
+	t.Setenv("FEEDER_READ_TIMEOUT", "10s")
+	t.Setenv("FEEDER_TLS", "true")
+
+	config, err := LoadConfig("feeder", []string{"-read-timeout", "1s", "-pool", "50", "-password", "secret"})
+
+	if err != nil {
+		t.Errorf("load error %s", err)
+		return
+	}
+
+	want := Config{
+		Engine:       "redis",
+		Host:         "redis.internal",
+		Port:         ":6379",
+		Db:           1,
+		Password:     "secret",
+		TLS:          true,
+		Pool:         50,
+		DialTimeout:  5 * time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: 3 * time.Second,
+	}
+
+	if config != want {
+		t.Errorf("got %+v want %+v", config, want)
+	}
+
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+
+	tt := []struct {
+		description string
+		env         map[string]string
+		args        []string
+		want        error
+	}{
+		{description: "rejects an empty required setting", env: map[string]string{"FEEDER_HOST": ""}, want: ErrInvalidConfig},
+		{description: "rejects an invalid environment variable", env: map[string]string{"FEEDER_POOL": "many"}, want: ErrInvalidConfig},
+		{description: "rejects an invalid flag", args: []string{"-dial-timeout", "soon"}, want: ErrInvalidConfig},
+		{description: "rejects an unknown flag", args: []string{"-cluster"}, want: ErrInvalidConfig},
+		{description: "asks for help", args: []string{"-h"}, want: flag.ErrHelp},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := LoadConfig("feeder", tc.args)
+
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got %v want %v", err, tc.want)
+			}
+
+		})
+	}
+
+}
+
+func TestConfigUsage(t *testing.T) {
+
+	usage := ConfigUsage("feeder")
+
+	want := []string{
+		"  -host string\n    \tdatabase Host URL (env FEEDER_HOST, default \"localhost\", required)\n",
+		"  -read-timeout duration\n    \ttimeout for reading a reply (env FEEDER_READ_TIMEOUT, default \"3s\")\n",
+		"  -tls bool\n",
+		"  -url string\n",
+	}
+
+	for _, line := range want {
+		if !strings.Contains(usage, line) {
+			t.Errorf("usage is missing %q got\n%s", line, usage)
+		}
+	}
+
+}
@@ -0,0 +1,126 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+var (
+	// ErrUserNotFound is returned when a user has no events or meta in a feed
+	ErrUserNotFound = errors.New("feeder: user not found")
+	// ErrBackendUnavailable is returned when the backend cannot be reached
+	ErrBackendUnavailable = errors.New("feeder: backend unavailable")
+	// ErrInvalidPage is returned when a page or page size is less than one
+	ErrInvalidPage = errors.New("feeder: invalid page")
+	// ErrCorruptMeta is returned when a counter or time stamp in the users meta cannot be read
+	ErrCorruptMeta = errors.New("feeder: corrupt meta")
+	// ErrTimeout is returned when a backend call runs past its deadline, either the deadline of
+	// its context or a timeout of the connection. A context error is wrapped along with it so
+	// errors.Is(err, context.DeadlineExceeded) keeps working
+	ErrTimeout = errors.New("feeder: timeout")
+	// ErrFeedExists is returned when a feed is registered under a name that is taken
+	ErrFeedExists = errors.New("feeder: feed already registered")
+	// ErrFeedNotFound is returned when no feed is registered under a name
+	ErrFeedNotFound = errors.New("feeder: feed not found")
+	// ErrInvalidFeed is returned when a feed without a name or backend is registered
+	ErrInvalidFeed = errors.New("feeder: invalid feed")
+	// ErrInvalidConfig is returned when a feeds configuration cannot be read or is invalid
+	ErrInvalidConfig = errors.New("feeder: invalid config")
+	// ErrUnknownEngine is returned when no driver is registered for an engine
+	ErrUnknownEngine = errors.New("feeder: unknown engine")
+	// ErrInvalidCursor is returned when a cursor was altered or handed out for another feed or user
+	ErrInvalidCursor = errors.New("feeder: invalid cursor")
+	// ErrInvalidPayload is returned when an action cannot be encoded or a stored payload cannot be decoded
+	ErrInvalidPayload = errors.New("feeder: invalid payload")
+	// ErrNotSupported is returned by backends that cannot run an operation, such as backends
+	// wrapped by FromLegacy for operations the LegacyBackend interface has no counterpart for
+	ErrNotSupported = errors.New("feeder: not supported")
+	// ErrInvalidFollow is returned when a user follows themselves or a follow misses a user
+	ErrInvalidFollow = errors.New("feeder: invalid follow")
+)
+
+// OpError is the error returned by backend operations, it records the operation,
+// feed and user the error happened on
+type OpError struct {
+	Op   string
+	Feed string
+	User string
+	Err  error
+}
+
+// Error describes the failed operation
+func (e *OpError) Error() string {
+	s := "feeder: " + e.Op
+	if e.Feed != "" {
+		s += " " + e.Feed
+	}
+	if e.User != "" {
+		s += " user " + e.User
+	}
+	return s + ": " + strings.TrimPrefix(e.Err.Error(), "feeder: ")
+}
+
+// Unwrap returns the underlying error
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// opError wraps err with the operation, feed and user it happened on
+func opError(op, feed, user string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var opErr *OpError
+	if errors.As(err, &opErr) {
+		return err
+	}
+
+	return &OpError{Op: op, Feed: feed, User: user, Err: err}
+}
+
+// contextError returns the error of a context that is already done, it is checked
+// before every call to a backend
+func contextError(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
+
+// backendError classifies an error returned by a backend connection, marking overrun
+// deadlines and connection timeouts with ErrTimeout and lost connections with ErrBackendUnavailable
+func backendError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if ctxErr := contextError(ctx); ctxErr != nil {
+		return ctxErr
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return fmt.Errorf("%w: %w", ErrBackendUnavailable, err)
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || unavailable(err) {
+		return fmt.Errorf("%w: %w", ErrBackendUnavailable, err)
+	}
+
+	return err
+}
+
+// unavailable reports the go-redis errors raised when no connection can be used
+func unavailable(err error) bool {
+	msg := err.Error()
+	return msg == "redis: client is closed" || msg == "redis: connection pool timeout"
+}
@@ -7,6 +7,12 @@ type Action struct {
 	Value  string    `json:"value"`
 	At     time.Time `json:"at"`
 	Method string    `json:"method"`
+	// Actor is who did the action and Object what it was done to, feeds with a GroupWindow
+	// group the actions sharing a method and object
+	Actor  string `json:"actor,omitempty"`
+	Object string `json:"object,omitempty"`
+	// Meta holds any other details of the action
+	Meta map[string]string `json:"meta,omitempty"`
 }
 
 // Event are the user actions a user does within the app that appear on the feed
@@ -17,6 +23,64 @@ type Event struct {
 	LastRead int64 	    `json:"last_read"`
 }
 
+// Item is an event read from a feed with the time it happened at
+type Item struct {
+	// ID is unique within the users feed, events stored with their value as their ID have the value
+	ID    string    `json:"id"`
+	Value string    `json:"value"`
+	At    time.Time `json:"at"`
+	// Score orders the feed, it is the time stamp of At unless the feed has a Scorer
+	Score float64 `json:"score"`
+	// Unread is true when the event happened at or after the user last read the feed
+	Unread bool `json:"unread"`
+	// Group is set for the groups of an aggregated feed, the item holds the latest action of the group
+	Group *Group `json:"group,omitempty"`
+	// Pinned is true while the event is pinned to the top of the feed, Priority is the priority
+	// it is trimmed by
+	Pinned   bool `json:"pinned"`
+	Priority int  `json:"priority"`
+}
+
+// newItem makes the item of an event scored score that happened at, lastRead is the users
+// last read time stamp
+func newItem(id, value string, score, at float64, lastRead int64) Item {
+	return Item{
+		ID:     id,
+		Value:  value,
+		At:     time.Unix(int64(at), 0),
+		Score:  score,
+		Unread: at >= float64(lastRead),
+	}
+}
+
+// withFlag overrides the watermark with the read flag of the event, "1" for read and "0" for
+// unread. Events without a flag are left as they are
+func (i Item) withFlag(flag string) Item {
+	if flag != "" {
+		i.Unread = flag == "0"
+	}
+	return i
+}
+
+// withPin marks the item pinned when it is pinned until 0, for until it is unpinned, or until
+// after now
+func (i Item) withPin(pinned bool, until, now int64) Item {
+	i.Pinned = pinned && (until == 0 || until > now)
+	return i
+}
+
+// Action decodes the item into an action, see DecodeAction. Actions that were stored
+// without a time happened at the time of the item
+func (i Item) Action() (Action, error) {
+	action, err := DecodeAction(i.Value)
+
+	if action.At.IsZero() {
+		action.At = i.At
+	}
+
+	return action, err
+}
+
 // NewEvent creates and instantiates a new user event
 func NewEvent(userID string) *Event {
 	event := &Event{
@@ -2,6 +2,7 @@ package feeder
 
 import (
 	"testing"
+	"time"
 )
 
 func TestNewEvent(t *testing.T) {
@@ -32,3 +33,49 @@ func TestNewEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestNewItem(t *testing.T) {
+	tt := []struct {
+		description string
+		at          float64
+		lastRead    int64
+		unread      bool
+	}{
+		{description: "happened after the last read", at: 20, lastRead: 10, unread: true},
+		{description: "happened at the last read", at: 10, lastRead: 10, unread: true},
+		{description: "happened before the last read", at: 5, lastRead: 10, unread: false},
+		{description: "never read", at: 5, lastRead: 0, unread: true},
+	}
+
+	for _, tc := range tt {
+
+		t.Run(tc.description, func(t *testing.T) {
+
+			got := newItem("01", "okandas followed you", 1, tc.at, tc.lastRead)
+
+			if got.Unread != tc.unread {
+				t.Errorf("item unread got %t want %t", got.Unread, tc.unread)
+			}
+
+			action, _ := got.Action()
+
+			if want := time.Unix(int64(tc.at), 0); !got.At.Equal(want) || !action.At.Equal(want) {
+				t.Errorf("item at got %s want %s", got.At, want)
+			}
+
+			if got.Score != 1 {
+				t.Errorf("item score got %v want 1", got.Score)
+			}
+
+		})
+	}
+}
+
+// itemValues returns the values of items
+func itemValues(items []Item) []string {
+	values := make([]string, len(items))
+	for i, item := range items {
+		values[i] = item.Value
+	}
+	return values
+}
@@ -1,5 +1,7 @@
 package feeder
 
+import "time"
+
 // Feeds are multiple feeds within the application
 type Feeds struct {
 	Feeds []Feed `json:"feeds"`
@@ -12,6 +14,57 @@ type Feed struct {
 	PerPage       int      `json:"per_page"`
 	Activities    []*Event `json:"activities"`
 	P	  		  Backend `json:"-"`
+	// CursorKey signs the cursors handed out by the feed, feeds without one use a key
+	// generated when the process starts so their cursors do not outlive it
+	CursorKey     []byte   `json:"-"`
+	// Codec encodes the actions stored with Activity.StoreAction, JSON when it is not set
+	Codec         Codec    `json:"-"`
+	// Dedupe stores events with their value as their ID, so storing a value again moves the
+	// event instead of adding another one
+	Dedupe        bool     `json:"dedupe"`
+	// GroupWindow aggregates the feed, actions stored with Activity.StoreAction that share a
+	// method and object within the window of the first of them are kept as one group
+	GroupWindow   time.Duration `json:"group_window"`
+	// Scorer ranks the feed, events are kept by the time they happened at when it is not set
+	Scorer        Scorer   `json:"-"`
+}
+
+// newID returns the ID of an event stored in the feed
+func (f *Feed) newID(value string, at int64) string {
+	if f.Dedupe {
+		return value
+	}
+	return NewID(time.Unix(at, 0))
+}
+
+// score returns the score of an action stored now
+func (f *Feed) score(action Action, now time.Time) float64 {
+	return scoreOf(f.Scorer, action, now)
+}
+
+// scoreItem returns the score of an event at now, events whose value does not decode to an
+// action are scored as an action holding the value. The action happened at the time of the item
+func (f *Feed) scoreItem(item Item, now time.Time) float64 {
+	if f.Scorer == nil {
+		return float64(item.At.Unix())
+	}
+
+	action, err := item.Action()
+
+	if err != nil {
+		action = Action{Value: item.Value}
+	}
+	action.At = item.At
+
+	return f.Scorer.Score(action, now)
+}
+
+// codec returns the codec actions are stored with
+func (f *Feed) codec() Codec {
+	if f.Codec == nil {
+		return JSON
+	}
+	return f.Codec
 }
 
 // NewFeed instantiates a new feed struct
@@ -25,5 +78,3 @@ func NewFeed(name string, size, perPage int,  b Backend) *Feed {
 	}
 
 }
-
-//TODO: should also register our feed in the register and then have a way of accessing that feed with a method
@@ -0,0 +1,1236 @@
+// Package feedertest checks that a feeder.Backend honours the contract the
+// Redis backend sets, so every backend behaves the same behind an Activity.
+package feedertest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/okandas/feeder"
+)
+
+// Factory returns a new, empty backend for a single test.
+// Use t to register any clean up the backend needs
+type Factory func(t *testing.T) feeder.Backend
+
+// feedSize is the size of the feed the suite scopes the backend to
+const feedSize = 5
+
+// user is the user the suite stores events for
+const user = "okandas"
+
+// RunBackendSuite runs the conformance tests against the backends returned by factory
+func RunBackendSuite(t *testing.T, factory Factory) {
+	tt := []struct {
+		name string
+		test func(t *testing.T, b feeder.Backend)
+	}{
+		{name: "HealthCheck", test: testHealthCheck},
+		{name: "Ordering", test: testOrdering},
+		{name: "Paginate", test: testPaginate},
+		{name: "Items", test: testItems},
+		{name: "InvalidPage", test: testInvalidPage},
+		{name: "Cursor", test: testCursor},
+		{name: "Between", test: testBetween},
+		{name: "IDs", test: testIDs},
+		{name: "Groups", test: testGroups},
+		{name: "Scores", test: testScores},
+		{name: "Pins", test: testPins},
+		{name: "Trim", test: testTrim},
+		{name: "Counters", test: testCounters},
+		{name: "Delete", test: testDelete},
+		{name: "Edit", test: testEdit},
+		{name: "UnRead", test: testUnRead},
+		{name: "Marks", test: testMarks},
+		{name: "UnreadCount", test: testUnreadCount},
+		{name: "Wipe", test: testWipe},
+		{name: "MissingUser", test: testMissingUser},
+		{name: "FeedIsolation", test: testFeedIsolation},
+		{name: "ConcurrentStore", test: testConcurrentStore},
+		{name: "Context", test: testContext},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, factory(t).WithFeed("suite", feedSize))
+		})
+	}
+}
+
+// store stores events valued "0", "1", ... scored by their index
+func store(t *testing.T, b feeder.Backend, count int) {
+	ctx := context.Background()
+
+	for i := 0; i < count; i++ {
+		if _, err := b.Store(ctx, user, strconv.Itoa(i), int64(i)); err != nil {
+			t.Fatalf("store error %s", err)
+		}
+	}
+}
+
+// equal reports whether got holds the events of want in the same order
+func equal(got []feeder.Item, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i].Value != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testHealthCheck(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	if _, err := b.HealthCheck(ctx); err != nil {
+		t.Errorf("health check error %s", err)
+	}
+}
+
+func testOrdering(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	b.Store(ctx, user, "second", 2)
+	b.Store(ctx, user, "first", 1)
+	b.Store(ctx, user, "third", 3)
+	// equal scores are ordered by value, highest first
+	b.Store(ctx, user, "a", 0)
+	b.Store(ctx, user, "b", 0)
+
+	got, err := b.All(ctx, user)
+
+	if err != nil {
+		t.Errorf("all error %s", err)
+		return
+	}
+
+	want := []string{"third", "second", "first", "b", "a"}
+
+	if !equal(got, want) {
+		t.Errorf("all got %v want %v", got, want)
+	}
+}
+
+func testPaginate(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	store(t, b, feedSize)
+
+	tt := []struct {
+		page    int
+		perPage int
+		want    []string
+	}{
+		{page: 1, perPage: 2, want: []string{"4", "3"}},
+		{page: 2, perPage: 2, want: []string{"2", "1"}},
+		{page: 3, perPage: 2, want: []string{"0"}},
+		{page: 4, perPage: 2, want: []string{}},
+		{page: 1, perPage: 10, want: []string{"4", "3", "2", "1", "0"}},
+	}
+
+	for _, tc := range tt {
+		got, err := b.Paginate(ctx, user, tc.page, tc.perPage)
+
+		if err != nil {
+			t.Errorf("paginate error %s", err)
+			return
+		}
+
+		if !equal(got, tc.want) {
+			t.Errorf("page %d of %d got %v want %v", tc.page, tc.perPage, got, tc.want)
+		}
+	}
+}
+
+func testItems(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	now := time.Now().Unix()
+
+	b.Store(ctx, user, "old", now-60)
+	b.Store(ctx, user, "new", now)
+	b.ResetLastRead(ctx, user, now-30)
+
+	got, err := b.All(ctx, user)
+
+	if err != nil {
+		t.Errorf("all error %s", err)
+		return
+	}
+
+	if !equal(got, []string{"new", "old"}) {
+		t.Errorf("all got %v want the new and the old event", got)
+		return
+	}
+
+	// backends wrapped by FromLegacy do not know when events happened
+	if got[0].At.IsZero() && got[1].At.IsZero() {
+		t.Skip("backend does not return when events happened")
+	}
+
+	want := []feeder.Item{
+		{Value: "new", At: time.Unix(now, 0), Unread: true},
+		{Value: "old", At: time.Unix(now-60, 0), Unread: false},
+	}
+
+	for i := range want {
+		if !got[i].At.Equal(want[i].At) || got[i].Unread != want[i].Unread {
+			t.Errorf("item %d got %+v want %+v", i, got[i], want[i])
+		}
+	}
+
+	page, _ := b.Paginate(ctx, user, 2, 1)
+
+	if len(page) != 1 || !page[0].At.Equal(want[1].At) || page[0].Unread {
+		t.Errorf("page 2 of 1 got %+v want %+v", page, want[1:])
+	}
+}
+
+func testCursor(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	// events at the same time are ordered by value, highest first
+	b.Store(ctx, user, "a", 1)
+	b.Store(ctx, user, "b", 2)
+	b.Store(ctx, user, "c", 2)
+	b.Store(ctx, user, "d", 2)
+	b.Store(ctx, user, "e", 3)
+
+	got, err := b.After(ctx, user, feeder.Cursor{}, 2)
+
+	if errors.Is(err, feeder.ErrNotSupported) {
+		t.Skip("backend does not support cursors")
+	}
+
+	if err != nil {
+		t.Errorf("after error %s", err)
+		return
+	}
+
+	tt := []struct {
+		name  string
+		read  func(cursor feeder.Cursor, limit int) ([]feeder.Item, error)
+		from  feeder.Cursor
+		limit int
+		want  []string
+	}{
+		{name: "after the newest", read: after(b), from: feeder.Cursor{Score: 3, ID: "e"}, limit: 2, want: []string{"d", "c"}},
+		{name: "after a tie", read: after(b), from: feeder.Cursor{Score: 2, ID: "c"}, limit: 2, want: []string{"b", "a"}},
+		{name: "after the oldest", read: after(b), from: feeder.Cursor{Score: 1, ID: "a"}, limit: 2, want: []string{}},
+		{name: "after a deleted event", read: after(b), from: feeder.Cursor{Score: 2, ID: "bb"}, limit: 5, want: []string{"b", "a"}},
+		{name: "before the oldest", read: before(b), from: feeder.Cursor{Score: 1, ID: "a"}, limit: 2, want: []string{"c", "b"}},
+		{name: "before a tie", read: before(b), from: feeder.Cursor{Score: 2, ID: "c"}, limit: 5, want: []string{"e", "d"}},
+		{name: "before the newest", read: before(b), from: feeder.Cursor{Score: 3, ID: "e"}, limit: 2, want: []string{}},
+	}
+
+	if !equal(got, []string{"e", "d"}) {
+		t.Errorf("after the zero cursor got %v want [e d]", got)
+	}
+
+	for _, tc := range tt {
+		got, err := tc.read(tc.from, tc.limit)
+
+		if err != nil {
+			t.Errorf("%s error %s", tc.name, err)
+			continue
+		}
+
+		if !equal(got, tc.want) {
+			t.Errorf("%s got %v want %v", tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := b.After(ctx, user, feeder.Cursor{}, 0); !errors.Is(err, feeder.ErrInvalidPage) {
+		t.Errorf("after with no limit got %v want %v", err, feeder.ErrInvalidPage)
+	}
+
+	if _, err := b.Before(ctx, user, feeder.Cursor{}, 2); !errors.Is(err, feeder.ErrInvalidCursor) {
+		t.Errorf("before the zero cursor got %v want %v", err, feeder.ErrInvalidCursor)
+	}
+}
+
+func testBetween(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	store(t, b, feedSize)
+
+	got, err := b.Between(ctx, user, 1, 3)
+
+	if errors.Is(err, feeder.ErrNotSupported) {
+		t.Skip("backend does not support time ranges")
+	}
+
+	if err != nil {
+		t.Errorf("between error %s", err)
+		return
+	}
+
+	tt := []struct {
+		from int64
+		to   int64
+		want []string
+	}{
+		{from: 1, to: 3, want: []string{"2", "1"}},
+		{from: 3, to: 3, want: []string{}},
+		{from: 4, to: 1, want: []string{}},
+		{from: math.MinInt64, to: 2, want: []string{"1", "0"}},
+		{from: 3, to: math.MaxInt64, want: []string{"4", "3"}},
+	}
+
+	if !equal(got, tt[0].want) {
+		t.Errorf("between 1 and 3 got %v want %v", got, tt[0].want)
+	}
+
+	for _, tc := range tt {
+		got, err := b.Between(ctx, user, tc.from, tc.to)
+
+		if err != nil {
+			t.Errorf("between %d and %d error %s", tc.from, tc.to, err)
+			continue
+		}
+
+		if !equal(got, tc.want) {
+			t.Errorf("between %d and %d got %v want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+// after reads the events after a cursor
+func after(b feeder.Backend) func(cursor feeder.Cursor, limit int) ([]feeder.Item, error) {
+	return func(cursor feeder.Cursor, limit int) ([]feeder.Item, error) {
+		return b.After(context.Background(), user, cursor, limit)
+	}
+}
+
+// before reads the events before a cursor
+func before(b feeder.Backend) func(cursor feeder.Cursor, limit int) ([]feeder.Item, error) {
+	return func(cursor feeder.Cursor, limit int) ([]feeder.Item, error) {
+		return b.Before(context.Background(), user, cursor, limit)
+	}
+}
+
+func testInvalidPage(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	store(t, b, feedSize)
+
+	tt := []struct {
+		page    int
+		perPage int
+	}{
+		{page: 0, perPage: 2},
+		{page: -1, perPage: 2},
+		{page: 1, perPage: 0},
+	}
+
+	for _, tc := range tt {
+		if _, err := b.Paginate(ctx, user, tc.page, tc.perPage); !errors.Is(err, feeder.ErrInvalidPage) {
+			t.Errorf("page %d of %d got %v want %v", tc.page, tc.perPage, err, feeder.ErrInvalidPage)
+		}
+	}
+}
+
+func testIDs(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	b.StoreID(ctx, user, "a1", "liked", 1, 1)
+	b.StoreID(ctx, user, "a2", "liked", 1, 1)
+
+	got, _ := b.All(ctx, user)
+
+	// backends wrapped by FromLegacy key events by value
+	if len(got) == 1 {
+		t.Skip("backend does not keep event IDs")
+	}
+
+	if !equal(got, []string{"liked", "liked"}) || got[0].ID != "a2" || got[1].ID != "a1" {
+		t.Errorf("all got %+v want both events ordered by ID", got)
+	}
+
+	count, _ := b.Count(ctx, user)
+
+	if count != 2 {
+		t.Errorf("count got %d want %d", count, 2)
+	}
+
+	// storing an ID again moves the event and replaces its value
+	b.StoreID(ctx, user, "a1", "shared", 2, 2)
+
+	got, _ = b.All(ctx, user)
+
+	if !equal(got, []string{"shared", "liked"}) || got[0].ID != "a1" {
+		t.Errorf("all after storing an ID again got %+v want %v", got, []string{"shared", "liked"})
+	}
+
+	// trimmed events take their values with them
+	for i := 0; i < feedSize; i++ {
+		b.StoreID(ctx, user, "b"+strconv.Itoa(i), "followed", int64(i+3), float64(i+3))
+	}
+
+	b.Store(ctx, user, "a2", 100)
+
+	got, _ = b.All(ctx, user)
+
+	if len(got) != feedSize || got[0].ID != "a2" || got[0].Value != "a2" {
+		t.Errorf("all after trimming got %+v want a2 to be its own value", got)
+	}
+
+	count, _ = b.Count(ctx, user)
+
+	if count != feedSize {
+		t.Errorf("count after trimming got %d want %d", count, feedSize)
+	}
+}
+
+func testGroups(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	const likes = "like\x00photo"
+
+	g, ok := b.(feeder.GroupBackend)
+
+	if !ok {
+		t.Skip("backend cannot group events")
+	}
+
+	change, err := g.StoreGroup(ctx, user, "g1", "a liked", 10, 10, likes, "a", 60)
+
+	if errors.Is(err, feeder.ErrNotSupported) {
+		t.Skip("backend cannot group events")
+	}
+
+	if err != nil || change != 1 {
+		t.Errorf("opening a group got %d, %v want %d", change, err, 1)
+	}
+
+	tt := []struct {
+		name   string
+		id     string
+		value  string
+		at     int64
+		key    string
+		actor  string
+		change int64
+		want   []string
+		group  feeder.Group
+	}{
+		{name: "joins the group", id: "g2", value: "b liked", at: 20, key: likes, actor: "b", change: 0,
+			want: []string{"b liked"}, group: feeder.Group{Actors: []string{"b", "a"}, Count: 2, First: time.Unix(10, 0)}},
+		{name: "moves an actor to the front", id: "g3", value: "a liked again", at: 30, key: likes, actor: "a", change: 0,
+			want: []string{"a liked again"}, group: feeder.Group{Actors: []string{"a", "b"}, Count: 3, First: time.Unix(10, 0)}},
+		{name: "keeps the latest action", id: "g4", value: "c liked", at: 15, key: likes, actor: "c", change: 0,
+			want: []string{"a liked again"}, group: feeder.Group{Actors: []string{"c", "a", "b"}, Count: 4, First: time.Unix(10, 0)}},
+		{name: "opens a group after the window", id: "g5", value: "d liked", at: 71, key: likes, actor: "d", change: 1,
+			want: []string{"d liked", "a liked again"}, group: feeder.Group{Actors: []string{"d"}, Count: 1, First: time.Unix(71, 0)}},
+		{name: "groups by object", id: "g6", value: "d liked a post", at: 72, key: "like\x00post", actor: "d", change: 1,
+			want: []string{"d liked a post", "d liked", "a liked again"}, group: feeder.Group{Actors: []string{"d"}, Count: 1, First: time.Unix(72, 0)}},
+	}
+
+	for _, tc := range tt {
+		change, err := g.StoreGroup(ctx, user, tc.id, tc.value, tc.at, float64(tc.at), tc.key, tc.actor, 60)
+
+		if err != nil || change != tc.change {
+			t.Errorf("%s got %d, %v want %d", tc.name, change, err, tc.change)
+		}
+
+		got, _ := b.All(ctx, user)
+
+		if !equal(got, tc.want) || got[0].Group == nil || !reflect.DeepEqual(*got[0].Group, tc.group) {
+			t.Errorf("%s got %+v want %v in %+v", tc.name, got, tc.want, tc.group)
+		}
+	}
+
+	count, _ := b.Count(ctx, user)
+	unread, _ := b.UnreadCount(ctx, user)
+
+	if count != 3 || unread != 3 {
+		t.Errorf("count %d and unread count %d want a count of %d for each group", count, unread, 3)
+	}
+
+	// a deleted group is closed, the next action opens another
+	b.DeleteID(ctx, user, "g5")
+
+	if change, _ := g.StoreGroup(ctx, user, "g7", "e liked", 73, 73, likes, "e", 60); change != 1 {
+		t.Errorf("storing after deleting the open group changed the count by %d want %d", change, 1)
+	}
+
+	// and so is a trimmed group
+	for i := 0; i < feedSize; i++ {
+		b.Store(ctx, user, "later "+strconv.Itoa(i), int64(80+i))
+	}
+
+	if change, _ := g.StoreGroup(ctx, user, "g8", "f liked", 101, 101, likes, "f", 60); change != 0 {
+		t.Errorf("storing after trimming the open group changed the count by %d want %d", change, 0)
+	}
+
+	got, _ := b.All(ctx, user)
+
+	if got[0].ID != "g8" || got[0].Group == nil || got[0].Group.Count != 1 {
+		t.Errorf("all after trimming the open group got %+v want a new group", got[0])
+	}
+}
+
+func testScores(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	// scored against the order of their times
+	events := []struct {
+		id    string
+		at    int64
+		score float64
+	}{
+		{id: "old", at: 10, score: 3},
+		{id: "new", at: 30, score: 1},
+		{id: "mid", at: 20, score: 2},
+	}
+
+	for _, e := range events {
+		if _, err := b.StoreID(ctx, user, e.id, e.id, e.at, e.score); errors.Is(err, feeder.ErrNotSupported) {
+			t.Skip("backend cannot score events")
+		}
+	}
+
+	got, _ := b.All(ctx, user)
+
+	if !equal(got, []string{"old", "mid", "new"}) || !got[0].At.Equal(time.Unix(10, 0)) || got[0].Score != 3 {
+		t.Errorf("all got %+v want the events by score keeping their time", got)
+	}
+
+	// events are read by the time they happened at
+	b.ResetLastRead(ctx, user, 20)
+
+	got, _ = b.All(ctx, user)
+	unread, _ := b.UnreadCount(ctx, user)
+	counted, _ := b.UnRead(ctx, user, 20)
+
+	if got[0].Unread || !got[1].Unread || !got[2].Unread || unread != 2 || counted != 2 {
+		t.Errorf("unread got %+v, a count of %d and %d want the two events since the last read", got, unread, counted)
+	}
+
+	between, _ := b.Between(ctx, user, 15, 30)
+
+	if !equal(between, []string{"mid"}) {
+		t.Errorf("between got %v want %v", between, []string{"mid"})
+	}
+
+	after, _ := b.After(ctx, user, got[0].Cursor(), 5)
+
+	if !equal(after, []string{"mid", "new"}) {
+		t.Errorf("after got %v want %v", after, []string{"mid", "new"})
+	}
+
+	rescorer, rescores := b.(feeder.RescoreBackend)
+	editor, edits := b.(feeder.EditBackend)
+	marker, marks := b.(feeder.MarkBackend)
+
+	if !rescores || !edits || !marks {
+		t.Skip("backend cannot rescore, move and mark events")
+	}
+
+	rescored, err := rescorer.Rescore(ctx, user, map[string]float64{"old": 10, "mid": 20, "new": 30, "missing": 40})
+
+	if err != nil || rescored != 3 {
+		t.Errorf("rescore got %d, %v want %d", rescored, err, 3)
+	}
+
+	got, _ = b.All(ctx, user)
+
+	if !equal(got, []string{"new", "mid", "old"}) || !got[2].At.Equal(time.Unix(10, 0)) || got[2].Score != 10 {
+		t.Errorf("all after rescoring got %+v want the events by time", got)
+	}
+
+	// a ranked event keeps its score when it is moved
+	rescorer.Rescore(ctx, user, map[string]float64{"old": 50})
+	editor.Move(ctx, user, "old", 40)
+
+	got, _ = b.All(ctx, user)
+
+	if got[0].ID != "old" || got[0].Score != 50 || !got[0].At.Equal(time.Unix(40, 0)) || !got[0].Unread {
+		t.Errorf("all after moving got %+v want old scored 50 and unread at 40", got[0])
+	}
+
+	if unread, _ := b.UnreadCount(ctx, user); unread != 3 {
+		t.Errorf("unread count after moving got %d want %d", unread, 3)
+	}
+
+	marker.MarkAllReadUpTo(ctx, user, "mid")
+
+	if unread, _ := b.UnreadCount(ctx, user); unread != 2 {
+		t.Errorf("unread count after marking up to mid got %d want %d", unread, 2)
+	}
+
+	// the lowest scores are trimmed
+	for i := 0; i < feedSize; i++ {
+		b.StoreID(ctx, user, "s"+strconv.Itoa(i), "scored", int64(100+i), float64(45+i))
+	}
+
+	got, _ = b.All(ctx, user)
+
+	if len(got) != feedSize || got[0].ID != "old" || got[1].ID != "s4" {
+		t.Errorf("all after trimming got %+v want the %d highest scores", got, feedSize)
+	}
+
+	unread, _ = b.UnreadCount(ctx, user)
+	recounted, _ := b.RecalculateUnread(ctx, user)
+
+	if unread != feedSize || recounted != unread {
+		t.Errorf("unread count after trimming got %d recounted %d want %d", unread, recounted, feedSize)
+	}
+}
+
+func testPins(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	p, ok := b.(feeder.PinBackend)
+
+	if !ok {
+		t.Skip("backend cannot pin events")
+	}
+
+	store(t, b, 3)
+
+	pinned, err := p.Pin(ctx, user, "0", 0)
+
+	if errors.Is(err, feeder.ErrNotSupported) {
+		t.Skip("backend cannot pin events")
+	}
+
+	if err != nil || !pinned {
+		t.Errorf("pin got %t, %v want the event pinned", pinned, err)
+	}
+
+	if pinned, _ = p.Pin(ctx, user, "missing", 0); pinned {
+		t.Errorf("pinning a missing event reported it existed")
+	}
+
+	got, _ := b.All(ctx, user)
+
+	if !equal(got, []string{"0", "2", "1"}) || !got[0].Pinned || got[1].Pinned {
+		t.Errorf("all got %+v want the pinned event first", got)
+	}
+
+	if page, _ := b.Paginate(ctx, user, 2, 2); !equal(page, []string{"1"}) {
+		t.Errorf("second page got %v want %v", page, []string{"1"})
+	}
+
+	// pinned events are not trimmed and higher priorities are trimmed last
+	if ok, err := p.SetPriority(ctx, user, "1", 1); err != nil || !ok {
+		t.Errorf("set priority got %t, %v want the priority set", ok, err)
+	}
+
+	for i := 3; i < 3+feedSize; i++ {
+		b.Store(ctx, user, strconv.Itoa(i), int64(i))
+	}
+
+	got, _ = b.All(ctx, user)
+
+	if !equal(got, []string{"0", "7", "6", "5", "4", "1"}) || got[5].Priority != 1 {
+		t.Errorf("all after trimming got %+v want %v", got, []string{"0", "7", "6", "5", "4", "1"})
+	}
+
+	if count, _ := b.Count(ctx, user); count != feedSize+1 {
+		t.Errorf("count with a pinned event got %d want %d", count, feedSize+1)
+	}
+
+	// an expired pin is trimmed like any other event
+	p.Pin(ctx, user, "0", 1)
+
+	got, _ = b.All(ctx, user)
+
+	if !equal(got, []string{"7", "6", "5", "4", "1", "0"}) || got[5].Pinned {
+		t.Errorf("all after the pin expired got %+v want the event in its place", got)
+	}
+
+	if change, _ := b.Store(ctx, user, "8", 8); change != -1 {
+		t.Errorf("store past an expired pin changed the count by %d want %d", change, -1)
+	}
+
+	got, _ = b.All(ctx, user)
+
+	if !equal(got, []string{"8", "7", "6", "5", "1"}) {
+		t.Errorf("all after trimming the expired pin got %v want %v", got, []string{"8", "7", "6", "5", "1"})
+	}
+
+	p.Pin(ctx, user, "5", 0)
+
+	if unpinned, err := p.Unpin(ctx, user, "5"); err != nil || !unpinned {
+		t.Errorf("unpin got %t, %v want the event unpinned", unpinned, err)
+	}
+
+	if unpinned, _ := p.Unpin(ctx, user, "missing"); unpinned {
+		t.Errorf("unpinning a missing event reported it existed")
+	}
+
+	p.Pin(ctx, user, "1", time.Now().Add(time.Hour).Unix())
+
+	got, _ = b.All(ctx, user)
+
+	if !equal(got, []string{"1", "8", "7", "6", "5"}) || !got[0].Pinned || got[4].Pinned {
+		t.Errorf("all after pinning until later got %+v want only 1 pinned", got)
+	}
+
+	unread, _ := b.UnreadCount(ctx, user)
+	recounted, _ := b.RecalculateUnread(ctx, user)
+
+	if unread != recounted {
+		t.Errorf("unread count got %d recounted %d", unread, recounted)
+	}
+}
+
+func testTrim(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	store(t, b, feedSize)
+
+	// a full feed evicts its oldest event
+	change, err := b.Store(ctx, user, "newest", feedSize)
+
+	if err != nil {
+		t.Errorf("store error %s", err)
+		return
+	}
+
+	if change != 0 {
+		t.Errorf("store on a full feed changed the count by %d want %d", change, 0)
+	}
+
+	got, _ := b.All(ctx, user)
+	want := []string{"newest", "4", "3", "2", "1"}
+
+	if !equal(got, want) {
+		t.Errorf("all got %v want %v", got, want)
+	}
+
+	// an event older than all others is evicted straight away
+	b.Store(ctx, user, "oldest", -1)
+
+	got, _ = b.All(ctx, user)
+
+	if !equal(got, want) {
+		t.Errorf("all after storing an old event got %v want %v", got, want)
+	}
+
+	count, _ := b.Count(ctx, user)
+
+	if count != feedSize {
+		t.Errorf("count got %d want %d", count, feedSize)
+	}
+}
+
+func testCounters(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	store(t, b, 3)
+
+	count, err := b.Count(ctx, user)
+
+	if err != nil {
+		t.Errorf("count error %s", err)
+		return
+	}
+
+	if count != 3 {
+		t.Errorf("count got %d want %d", count, 3)
+	}
+
+	// storing an event again only moves it
+	change, _ := b.Store(ctx, user, "1", 10)
+
+	if change != 0 {
+		t.Errorf("storing an existing event changed the count by %d want %d", change, 0)
+	}
+
+	count, _ = b.Count(ctx, user)
+
+	if count != 3 {
+		t.Errorf("count after storing an existing event got %d want %d", count, 3)
+	}
+
+	if _, err := b.RecalculateCount(ctx, user); err != nil {
+		t.Errorf("recalculate count error %s", err)
+		return
+	}
+
+	count, _ = b.Count(ctx, user)
+
+	if count != 3 {
+		t.Errorf("count after recalculating got %d want %d", count, 3)
+	}
+}
+
+func testDelete(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	store(t, b, 3)
+
+	removed, err := b.Delete(ctx, user, "1", 1)
+
+	if err != nil {
+		t.Errorf("delete error %s", err)
+		return
+	}
+
+	if removed != 1 {
+		t.Errorf("delete removed %d want %d", removed, 1)
+	}
+
+	removed, _ = b.Delete(ctx, user, "1", 1)
+
+	if removed != 0 {
+		t.Errorf("deleting a missing event removed %d want %d", removed, 0)
+	}
+
+	got, _ := b.All(ctx, user)
+	want := []string{"2", "0"}
+
+	if !equal(got, want) {
+		t.Errorf("all got %v want %v", got, want)
+	}
+
+	count, _ := b.Count(ctx, user)
+
+	if count != 2 {
+		t.Errorf("count got %d want %d", count, 2)
+	}
+}
+
+func testEdit(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	store(t, b, 3)
+
+	deleted, err := b.DeleteID(ctx, user, "1")
+
+	if err != nil {
+		t.Errorf("delete error %s", err)
+		return
+	}
+
+	if !deleted {
+		t.Errorf("delete reported event %q missing", "1")
+	}
+
+	if deleted, _ = b.DeleteID(ctx, user, "1"); deleted {
+		t.Errorf("deleting a missing event reported it existed")
+	}
+
+	count, _ := b.Count(ctx, user)
+
+	if count != 2 {
+		t.Errorf("count after delete got %d want %d", count, 2)
+	}
+
+	editor, ok := b.(feeder.EditBackend)
+
+	if !ok {
+		t.Skip("backend cannot edit events")
+	}
+
+	updated, err := editor.Update(ctx, user, "0", "edited")
+
+	if errors.Is(err, feeder.ErrNotSupported) {
+		t.Skip("backend cannot edit events")
+	}
+
+	if err != nil || !updated {
+		t.Errorf("update got %t, %v want the event updated", updated, err)
+	}
+
+	if updated, _ = editor.Update(ctx, user, "missing", "edited"); updated {
+		t.Errorf("updating a missing event reported it existed")
+	}
+
+	got, _ := b.All(ctx, user)
+
+	if !equal(got, []string{"2", "edited"}) || got[1].ID != "0" {
+		t.Errorf("all after update got %+v want the event edited in place", got)
+	}
+
+	moved, err := editor.Move(ctx, user, "0", 10)
+
+	if err != nil || !moved {
+		t.Errorf("move got %t, %v want the event moved", moved, err)
+	}
+
+	if moved, _ = editor.Move(ctx, user, "missing", 10); moved {
+		t.Errorf("moving a missing event reported it existed")
+	}
+
+	got, _ = b.All(ctx, user)
+
+	if !equal(got, []string{"edited", "2"}) {
+		t.Errorf("all after move got %v want %v", got, []string{"edited", "2"})
+	}
+
+	count, _ = b.Count(ctx, user)
+
+	if count != 2 {
+		t.Errorf("count after move got %d want %d", count, 2)
+	}
+}
+
+func testUnRead(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	store(t, b, feedSize)
+
+	created, err := b.ResetLastRead(ctx, user, 3)
+
+	if err != nil {
+		t.Errorf("reset last read error %s", err)
+		return
+	}
+
+	if !created {
+		t.Errorf("first reset of last read should create it")
+	}
+
+	lastRead, err := b.LastRead(ctx, user)
+
+	if err != nil {
+		t.Errorf("last read error %s", err)
+		return
+	}
+
+	if lastRead != 3 {
+		t.Errorf("last read got %d want %d", lastRead, 3)
+	}
+
+	// events at or after the last read are unread
+	unread, err := b.UnRead(ctx, user, lastRead)
+
+	if err != nil {
+		t.Errorf("unread error %s", err)
+		return
+	}
+
+	if unread != 2 {
+		t.Errorf("unread got %d want %d", unread, 2)
+	}
+
+	created, _ = b.ResetLastRead(ctx, user, feedSize)
+
+	if created {
+		t.Errorf("second reset of last read should overwrite it")
+	}
+
+	unread, _ = b.UnRead(ctx, user, feedSize)
+
+	if unread != 0 {
+		t.Errorf("unread after reading everything got %d want %d", unread, 0)
+	}
+}
+
+func testMarks(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	marker, ok := b.(feeder.MarkBackend)
+
+	if !ok {
+		t.Skip("backend cannot mark single events")
+	}
+
+	store(t, b, 5)
+	b.ResetLastRead(ctx, user, 3)
+
+	changed, err := marker.MarkRead(ctx, user, "4")
+
+	if errors.Is(err, feeder.ErrNotSupported) {
+		t.Skip("backend cannot mark single events")
+	}
+
+	if err != nil || changed != 1 {
+		t.Errorf("mark read got %d, %v want %d", changed, err, 1)
+	}
+
+	if changed, _ = marker.MarkRead(ctx, user, "4"); changed != 0 {
+		t.Errorf("marking a read event read changed %d want %d", changed, 0)
+	}
+
+	if changed, _ = marker.MarkUnread(ctx, user, "1", "missing"); changed != 1 {
+		t.Errorf("mark unread changed %d want %d", changed, 1)
+	}
+
+	// unread returns the IDs of the unread events, newest first
+	unread := func() []string {
+		items, _ := b.All(ctx, user)
+		ids := []string{}
+		for _, item := range items {
+			if item.Unread {
+				ids = append(ids, item.ID)
+			}
+		}
+		return ids
+	}
+
+	tt := []struct {
+		name     string
+		mark     func()
+		lastRead int64
+		want     []string
+	}{
+		{name: "marks", mark: func() {}, lastRead: 3, want: []string{"3", "1"}},
+		{name: "up to an older event", mark: func() { marker.MarkAllReadUpTo(ctx, user, "1") }, lastRead: 3, want: []string{"3"}},
+		{name: "up to a tie", mark: func() {
+			b.Store(ctx, user, "t1", 6)
+			b.Store(ctx, user, "t2", 6)
+			b.Store(ctx, user, "t3", 6)
+			marker.MarkAllReadUpTo(ctx, user, "t2")
+		}, lastRead: 7, want: []string{"t3"}},
+		{name: "reset", mark: func() { b.ResetLastRead(ctx, user, 6) }, lastRead: 6, want: []string{"t3", "t2", "t1"}},
+	}
+
+	for _, tc := range tt {
+		tc.mark()
+
+		if got := unread(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s unread got %v want %v", tc.name, got, tc.want)
+		}
+
+		if count, _ := b.UnRead(ctx, user, tc.lastRead); count != int64(len(tc.want)) {
+			t.Errorf("%s unread count got %d want %d", tc.name, count, len(tc.want))
+		}
+	}
+
+	if found, _ := marker.MarkAllReadUpTo(ctx, user, "missing"); found {
+		t.Errorf("marking up to a missing event reported it existed")
+	}
+}
+
+func testUnreadCount(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	// the kept unread count must follow the unread events counted from the data
+	check := func(step string, want int64) {
+		lastRead, _ := b.LastRead(ctx, user)
+		counted, _ := b.UnRead(ctx, user, lastRead)
+		kept, err := b.UnreadCount(ctx, user)
+
+		if err != nil || kept != counted || kept != want {
+			t.Errorf("%s unread count got %d, %v counted %d want %d", step, kept, err, counted, want)
+		}
+	}
+
+	store(t, b, 3)
+	check("store", 3)
+
+	b.ResetLastRead(ctx, user, 2)
+	check("reset", 1)
+
+	b.Store(ctx, user, "old", 1)
+	check("store a read event", 1)
+
+	b.Store(ctx, user, "1", 4)
+	check("store a read event again", 2)
+
+	store(t, b, feedSize)
+	check("trim", 3)
+
+	b.Delete(ctx, user, "4", 0)
+	check("delete", 2)
+
+	if marker, ok := b.(feeder.MarkBackend); ok {
+		if _, err := marker.MarkRead(ctx, user, "3"); err == nil {
+			check("mark read", 1)
+		}
+	}
+
+	b.Wipe(ctx, user)
+
+	if _, err := b.UnreadCount(ctx, user); !errors.Is(err, feeder.ErrUserNotFound) {
+		t.Errorf("unread count after wipe got %v want %v", err, feeder.ErrUserNotFound)
+	}
+
+	store(t, b, 2)
+
+	if count, err := b.RecalculateUnread(ctx, user); err != nil || count != 2 {
+		t.Errorf("recalculate unread got %d, %v want %d", count, err, 2)
+	}
+}
+
+func testWipe(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	store(t, b, 3)
+	b.ResetLastRead(ctx, user, 1)
+
+	removed, err := b.Wipe(ctx, user)
+
+	if err != nil {
+		t.Errorf("wipe error %s", err)
+		return
+	}
+
+	if removed != 3 {
+		t.Errorf("wipe removed %d want %d", removed, 3)
+	}
+
+	got, _ := b.All(ctx, user)
+
+	if len(got) != 0 {
+		t.Errorf("wipe left events behind %v", got)
+	}
+
+	// wipe resets the counters and the last read time stamp
+	if _, err := b.Count(ctx, user); !errors.Is(err, feeder.ErrUserNotFound) {
+		t.Errorf("count after wipe got %v want %v", err, feeder.ErrUserNotFound)
+	}
+
+	if _, err := b.LastRead(ctx, user); !errors.Is(err, feeder.ErrUserNotFound) {
+		t.Errorf("last read after wipe got %v want %v", err, feeder.ErrUserNotFound)
+	}
+
+	store(t, b, 1)
+
+	count, _ := b.Count(ctx, user)
+
+	if count != 1 {
+		t.Errorf("count after storing into a wiped feed got %d want %d", count, 1)
+	}
+}
+
+func testMissingUser(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	all, err := b.All(ctx, user)
+
+	if err != nil || len(all) != 0 {
+		t.Errorf("all got %v %v want no events", all, err)
+	}
+
+	page, err := b.Paginate(ctx, user, 1, 10)
+
+	if err != nil || len(page) != 0 {
+		t.Errorf("paginate got %v %v want no events", page, err)
+	}
+
+	unread, err := b.UnRead(ctx, user, 0)
+
+	if err != nil || unread != 0 {
+		t.Errorf("unread got %d %v want 0", unread, err)
+	}
+
+	removed, err := b.Delete(ctx, user, "missing", 0)
+
+	if err != nil || removed != 0 {
+		t.Errorf("delete got %d %v want 0", removed, err)
+	}
+
+	removed, err = b.Wipe(ctx, user)
+
+	if err != nil || removed != 0 {
+		t.Errorf("wipe got %d %v want 0", removed, err)
+	}
+
+	_, err = b.Count(ctx, user)
+
+	if !errors.Is(err, feeder.ErrUserNotFound) {
+		t.Errorf("count of a missing user got %v want %v", err, feeder.ErrUserNotFound)
+	}
+
+	// errors carry the operation, feed and user they happened on
+	var opErr *feeder.OpError
+
+	if !errors.As(err, &opErr) || opErr.Feed != "suite" || opErr.User != user {
+		t.Errorf("count of a missing user got %#v want an OpError on feed suite and user %s", err, user)
+	}
+
+	if _, err := b.LastRead(ctx, user); !errors.Is(err, feeder.ErrUserNotFound) {
+		t.Errorf("last read of a missing user got %v want %v", err, feeder.ErrUserNotFound)
+	}
+}
+
+func testFeedIsolation(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	other := b.WithFeed("other", feedSize)
+
+	store(t, b, 2)
+	other.Store(ctx, user, "other", 1)
+
+	got, _ := b.All(ctx, user)
+	want := []string{"1", "0"}
+
+	if !equal(got, want) {
+		t.Errorf("all got %v want %v", got, want)
+	}
+
+	got, _ = other.All(ctx, user)
+	want = []string{"other"}
+
+	if !equal(got, want) {
+		t.Errorf("all of the other feed got %v want %v", got, want)
+	}
+
+	other.Wipe(ctx, user)
+
+	count, _ := b.Count(ctx, user)
+
+	if count != 2 {
+		t.Errorf("wiping another feed changed the count to %d want %d", count, 2)
+	}
+}
+
+func testConcurrentStore(t *testing.T, b feeder.Backend) {
+	ctx := context.Background()
+
+	const writers = 50
+
+	b = b.WithFeed("concurrent", writers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := b.Store(ctx, user, strconv.Itoa(i), int64(i)); err != nil {
+				t.Errorf("store error %s", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	all, _ := b.All(ctx, user)
+
+	if len(all) != writers {
+		t.Errorf("all got %d events want %d", len(all), writers)
+	}
+
+	count, _ := b.Count(ctx, user)
+
+	if count != writers {
+		t.Errorf("count got %d want %d", count, writers)
+	}
+}
+
+func testContext(t *testing.T, b feeder.Backend) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := b.Store(ctx, user, "cancelled", 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("store with a cancelled context got %v want %v", err, context.Canceled)
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := b.All(ctx, user)
+
+	if !errors.Is(err, feeder.ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("all past the deadline got %v want %v and %v", err, feeder.ErrTimeout, context.DeadlineExceeded)
+	}
+
+	all, _ := b.All(context.Background(), user)
+
+	if len(all) != 0 {
+		t.Errorf("a store with a cancelled context should not be made, got %v", all)
+	}
+}
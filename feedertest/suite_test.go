@@ -0,0 +1,135 @@
+package feedertest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis"
+	"github.com/okandas/feeder"
+)
+
+func TestRedis(t *testing.T) {
+	RunBackendSuite(t, func(t *testing.T) feeder.Backend {
+		server, err := miniredis.Run()
+		if err != nil {
+			panic(err)
+		}
+		t.Cleanup(server.Close)
+
+		c := redis.NewClient(&redis.Options{
+			Addr: server.Addr(),
+		})
+
+		return feeder.NewRedisClient(c)
+	})
+}
+
+func TestMemory(t *testing.T) {
+	RunBackendSuite(t, func(t *testing.T) feeder.Backend {
+		return feeder.NewMemoryBackend()
+	})
+}
+
+func TestLegacy(t *testing.T) {
+	RunBackendSuite(t, func(t *testing.T) feeder.Backend {
+		return feeder.FromLegacy(legacy{b: unbounded()})
+	})
+}
+
+func TestLegacyFeeds(t *testing.T) {
+	ctx := context.Background()
+
+	inner := unbounded()
+	b := feeder.FromLegacy(legacy{b: inner})
+
+	b.WithFeed("news", 2).Store(ctx, user, "first", 1)
+	b.WithFeed("news", 2).Store(ctx, user, "second", 2)
+	b.WithFeed("news", 2).Store(ctx, user, "third", 3)
+	b.WithFeed("alerts", 2).Store(ctx, user, "alert", 1)
+
+	if count, _ := b.WithFeed("news", 2).Count(ctx, user); count != 2 {
+		t.Errorf("news count got %d want %d", count, 2)
+	}
+
+	if count, _ := b.WithFeed("alerts", 2).Count(ctx, user); count != 1 {
+		t.Errorf("alerts count got %d want %d", count, 1)
+	}
+
+	// the wrapped backend has a user per feed
+	if count, _ := inner.Count(ctx, "news:"+user); count != 2 {
+		t.Errorf("wrapped news count got %d want %d", count, 2)
+	}
+}
+
+// unbounded returns a backend that keeps every event, like most legacy backends do, so only
+// the adapter trims feeds
+func unbounded() feeder.Backend {
+	return feeder.NewMemoryBackend().WithFeed("", 1<<20)
+}
+
+// legacy implements the LegacyBackend interface on top of a Backend with only the methods of
+// the Backend interface before calls took a context
+type legacy struct {
+	b feeder.Backend
+}
+
+func (l legacy) Store(user, value string, at int64) (int64, error) {
+	return l.b.Store(context.Background(), user, value, at)
+}
+
+func (l legacy) Delete(user, value string, at int64) (int64, error) {
+	return l.b.Delete(context.Background(), user, value, at)
+}
+
+func (l legacy) HealthCheck() (string, error) {
+	return l.b.HealthCheck(context.Background())
+}
+
+func (l legacy) Wipe(user string) (int64, error) {
+	return l.b.Wipe(context.Background(), user)
+}
+
+func (l legacy) All(user string) ([]string, error) {
+	items, err := l.b.All(context.Background(), user)
+	return values(items), err
+}
+
+func (l legacy) Paginate(user string, page, perPage int) ([]string, error) {
+	items, err := l.b.Paginate(context.Background(), user, page, perPage)
+	return values(items), err
+}
+
+func (l legacy) Count(user string) (int, error) {
+	return l.b.Count(context.Background(), user)
+}
+
+func (l legacy) UnRead(user string, at int64) (int64, error) {
+	return l.b.UnRead(context.Background(), user, at)
+}
+
+func (l legacy) ResetLastRead(user string, at int64) (bool, error) {
+	return l.b.ResetLastRead(context.Background(), user, at)
+}
+
+func (l legacy) LastRead(user string) (int64, error) {
+	return l.b.LastRead(context.Background(), user)
+}
+
+func (l legacy) RecalculateCount(user string) (int64, error) {
+	return l.b.RecalculateCount(context.Background(), user)
+}
+
+// values returns the values of items, as a legacy backend returns them
+func values(items []feeder.Item) []string {
+	if items == nil {
+		return nil
+	}
+
+	values := make([]string, len(items))
+	for i, item := range items {
+		values[i] = item.Value
+	}
+
+	return values
+}
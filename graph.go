@@ -0,0 +1,143 @@
+package feeder
+
+import (
+	"context"
+	"fmt"
+)
+
+// Graph keeps who follows whom. Followers and Following list the latest follows first
+type Graph interface {
+	// Follow makes follower follow followee and reports whether it did not already
+	Follow(ctx context.Context, follower, followee string) (bool, error)
+	// Unfollow stops follower following followee and reports whether it did
+	Unfollow(ctx context.Context, follower, followee string) (bool, error)
+	// Followers paginates the users following user, pages start at one
+	Followers(ctx context.Context, user string, page, perPage int) ([]string, error)
+	// FollowersAfter returns up to limit follows of the users following user made before after,
+	// the latest first. The zero Follow starts at the latest, pass the last follow of a page to
+	// read on. Follows made or undone in between do not move the follows still to be read
+	FollowersAfter(ctx context.Context, user string, after Follow, limit int) ([]Follow, error)
+	// Following returns every user that user follows
+	Following(ctx context.Context, user string) ([]string, error)
+}
+
+// Follow is a user following or followed along with the second the follow was made at
+type Follow struct {
+	User string `json:"user"`
+	At   int64  `json:"at"`
+}
+
+// IsZero reports whether f is the zero Follow, the start of FollowersAfter
+func (f Follow) IsZero() bool {
+	return f.User == "" && f.At == 0
+}
+
+// before reports whether a follow made at at by user comes after f, the latest first and by
+// user ID descending within the same second
+func (f Follow) before(user string, at int64) bool {
+	return at < f.At || (at == f.At && user < f.User)
+}
+
+// checkFollow checks that a follow is between two different users
+func checkFollow(follower, followee string) error {
+	if follower == "" || followee == "" {
+		return fmt.Errorf("%w: a follow needs a follower and a followee", ErrInvalidFollow)
+	}
+
+	if follower == followee {
+		return fmt.Errorf("%w: %s cannot follow themselves", ErrInvalidFollow, follower)
+	}
+
+	return nil
+}
+
+// DefaultPublishBatch is the number of followers a Publisher stores an event for at once when
+// it has no BatchSize
+const DefaultPublishBatch = 1000
+
+// Publisher writes the events of actors into the feed of every follower as they are published,
+// so reading a feed costs the same however many users its owner follows
+type Publisher struct {
+	Graph Graph
+	Feed  *Feed
+	// BatchSize is the number of followers stored at once, DefaultPublishBatch when 0
+	BatchSize int
+}
+
+// Publish stores an action of actor in the feed of each of its followers and returns a Result
+// per follower, the action is stored for the followers of one batch at a time. The followers
+// are read with FollowersAfter so users following or unfollowing during a publish do not make
+// it skip or repeat others. Feeds with a GroupWindow group the action in the feed of each
+// follower one by one. The error is set when the followers could not be read, the followers
+// stored until then keep the action
+func (p Publisher) Publish(ctx context.Context, actor string, action Action) (map[string]Result, error) {
+	if action.Actor == "" {
+		action.Actor = actor
+	}
+
+	value, err := EncodeAction(p.Feed.codec(), action)
+
+	if err != nil {
+		return nil, opError("publish", p.Feed.Name, actor, err)
+	}
+
+	size := p.BatchSize
+	if size <= 0 {
+		size = DefaultPublishBatch
+	}
+
+	results := map[string]Result{}
+
+	var after Follow
+
+	for {
+		follows, err := p.Graph.FollowersAfter(ctx, actor, after, size)
+
+		if err != nil {
+			return results, err
+		}
+
+		if len(follows) == 0 {
+			return results, nil
+		}
+
+		followers := make([]string, len(follows))
+		for i, follow := range follows {
+			followers[i] = follow.User
+		}
+
+		for user, result := range p.store(ctx, followers, value, action) {
+			results[user] = result
+		}
+
+		after = follows[len(follows)-1]
+	}
+}
+
+// store stores an action for a batch of followers
+func (p Publisher) store(ctx context.Context, followers []string, value string, action Action) map[string]Result {
+	if len(followers) == 0 {
+		return nil
+	}
+
+	if p.Feed.GroupWindow <= 0 || action.Method == "" {
+		return NewBatchActivity(followers, p.Feed).Store(ctx, value, action.At.Unix())
+	}
+
+	results := make(map[string]Result, len(followers))
+
+	for _, user := range followers {
+		change, err := NewActivity(user, p.Feed).StoreAction(ctx, action)
+		results[user] = Result{Value: change, Err: err}
+	}
+
+	return results
+}
+
+// NewPublisher instantiates a publisher of the actions of the users of graph into feed
+func NewPublisher(graph Graph, feed *Feed) *Publisher {
+	return &Publisher{
+		Graph: graph,
+		Feed:  feed,
+	}
+}
@@ -0,0 +1,260 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGraph(t *testing.T) {
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	graphs := []struct {
+		name  string
+		graph Graph
+	}{
+		{"redis", NewRedisGraph(c)},
+		{"memory", NewMemoryGraph()},
+	}
+
+	for _, g := range graphs {
+		t.Run(g.name, func(t *testing.T) {
+			graph := g.graph
+
+			for _, follower := range []string{"farai", "rudo", "tendai"} {
+				if followed, err := graph.Follow(ctx, follower, "okandas"); err != nil || !followed {
+					t.Errorf("follow got %t, %v want %s following okandas", followed, err, follower)
+				}
+			}
+
+			if followed, err := graph.Follow(ctx, "farai", "okandas"); err != nil || followed {
+				t.Errorf("follow got %t, %v want the follow kept", followed, err)
+			}
+
+			graph.Follow(ctx, "farai", "rudo")
+
+			pages := []struct {
+				page, perPage int
+				want          []string
+			}{
+				// follows of the same second are ordered by user ID
+				{1, 2, []string{"tendai", "rudo"}},
+				{2, 2, []string{"farai"}},
+				{3, 2, []string{}},
+			}
+
+			for _, p := range pages {
+				followers, err := graph.Followers(ctx, "okandas", p.page, p.perPage)
+
+				if err != nil || !cmp.Equal(followers, p.want) {
+					t.Errorf("page %d of %d got %v, %v want %v", p.page, p.perPage, followers, err, p.want)
+				}
+			}
+
+			var follows []string
+			var after Follow
+
+			for {
+				page, err := graph.FollowersAfter(ctx, "okandas", after, 2)
+
+				if err != nil || len(page) == 0 {
+					break
+				}
+
+				for _, follow := range page {
+					follows = append(follows, follow.User)
+				}
+				after = page[len(page)-1]
+			}
+
+			if !cmp.Equal(follows, []string{"tendai", "rudo", "farai"}) || after.At == 0 {
+				t.Errorf("followers after got %v ending at %+v want every follower", follows, after)
+			}
+
+			if following, err := graph.Following(ctx, "farai"); err != nil || !cmp.Equal(following, []string{"rudo", "okandas"}) {
+				t.Errorf("got %v, %v want farai following rudo and okandas", following, err)
+			}
+
+			if unfollowed, err := graph.Unfollow(ctx, "rudo", "okandas"); err != nil || !unfollowed {
+				t.Errorf("unfollow got %t, %v want rudo no longer following", unfollowed, err)
+			}
+
+			if unfollowed, err := graph.Unfollow(ctx, "rudo", "okandas"); err != nil || unfollowed {
+				t.Errorf("unfollow got %t, %v want nothing to unfollow", unfollowed, err)
+			}
+
+			if followers, _ := graph.Followers(ctx, "okandas", 1, 10); !cmp.Equal(followers, []string{"tendai", "farai"}) {
+				t.Errorf("got %v want tendai and farai", followers)
+			}
+
+			if following, err := graph.Following(ctx, "nobody"); err != nil || len(following) != 0 {
+				t.Errorf("got %v, %v want no follows", following, err)
+			}
+
+			if _, err := graph.Follow(ctx, "okandas", "okandas"); !errors.Is(err, ErrInvalidFollow) {
+				t.Errorf("got %v want %v", err, ErrInvalidFollow)
+			}
+
+			if _, err := graph.Followers(ctx, "okandas", 0, 10); !errors.Is(err, ErrInvalidPage) {
+				t.Errorf("got %v want %v", err, ErrInvalidPage)
+			}
+		})
+	}
+}
+
+func TestMemoryGraphZeroValue(t *testing.T) {
+	ctx := context.Background()
+
+	var graph MemoryGraph
+
+	if followers, err := graph.Followers(ctx, "okandas", 1, 10); err != nil || len(followers) != 0 {
+		t.Errorf("got %v, %v want no followers", followers, err)
+	}
+
+	if followed, err := graph.Follow(ctx, "farai", "okandas"); err != nil || !followed {
+		t.Errorf("follow got %t, %v want farai following okandas", followed, err)
+	}
+
+	if followers, _ := graph.Followers(ctx, "okandas", 1, 10); !cmp.Equal(followers, []string{"farai"}) {
+		t.Errorf("got %v want farai", followers)
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	graph := NewRedisGraph(c)
+
+	for i := 0; i < 5; i++ {
+		graph.Follow(ctx, "follower"+strconv.Itoa(i), "okandas")
+	}
+	graph.Follow(ctx, "okandas", "farai")
+
+	feed := NewFeed("timeline", 10, 10, NewRedisClient(c))
+	publisher := NewPublisher(graph, feed)
+	publisher.BatchSize = 2
+
+	results, err := publisher.Publish(ctx, "okandas", Action{Value: "posted a photo", At: time.Unix(1700000000, 0)})
+
+	if err != nil || len(results) != 5 {
+		t.Fatalf("got %d results, %v want one per follower", len(results), err)
+	}
+
+	for i := 0; i < 5; i++ {
+		user := "follower" + strconv.Itoa(i)
+
+		if results[user].Err != nil {
+			t.Errorf("%s got %v", user, results[user].Err)
+		}
+
+		items, _ := NewActivity(user, feed).All(ctx)
+
+		if len(items) != 1 {
+			t.Fatalf("%s got %d events want the published action", user, len(items))
+		}
+
+		if action, err := items[0].Action(); err != nil || action.Actor != "okandas" || action.Value != "posted a photo" {
+			t.Errorf("%s got %+v, %v want the action of okandas", user, action, err)
+		}
+	}
+
+	// the actor and the users it follows do not get its actions
+	for _, user := range []string{"okandas", "farai"} {
+		if items, _ := NewActivity(user, feed).All(ctx); len(items) != 0 {
+			t.Errorf("%s got %v want no events", user, itemValues(items))
+		}
+	}
+
+	// users following and unfollowing during a publish do not make it skip or repeat others
+	changes := []struct {
+		name   string
+		change func(graph Graph)
+	}{
+		{"follow", func(graph Graph) { graph.Follow(ctx, "zodwa", "okandas") }},
+		{"unfollow", func(graph Graph) { graph.Unfollow(ctx, "f4", "okandas") }},
+	}
+
+	for _, change := range changes {
+		for _, g := range []Graph{NewMemoryGraph(), RedisGraph{C: c, Prefix: change.name}} {
+			for i := 0; i < 5; i++ {
+				g.Follow(ctx, "f"+strconv.Itoa(i), "okandas")
+			}
+
+			feed := NewFeed(change.name, 10, 10, NewMemoryBackend())
+			publisher := NewPublisher(changing{Graph: g, change: change.change}, feed)
+			publisher.BatchSize = 2
+
+			publisher.Publish(ctx, "okandas", Action{Value: "posted a photo", At: time.Unix(1700000000, 0)})
+
+			for i := 0; i < 5; i++ {
+				user := "f" + strconv.Itoa(i)
+
+				if items, _ := NewActivity(user, feed).All(ctx); len(items) != 1 {
+					t.Errorf("%s %T: %s got %d events want the action once", change.name, g, user, len(items))
+				}
+			}
+		}
+	}
+
+	grouped := NewFeed("notifications", 10, 10, NewMemoryBackend())
+	grouped.GroupWindow = time.Hour
+	publisher = NewPublisher(NewMemoryGraph(), grouped)
+	publisher.Graph.Follow(ctx, "farai", "okandas")
+
+	for _, actor := range []string{"rudo", "tendai"} {
+		publisher.Graph.Follow(ctx, "farai", actor)
+		publisher.Publish(ctx, actor, Action{Value: "liked", Method: "like", Object: "photo", At: time.Unix(1700000000, 0)})
+	}
+
+	items, _ := NewActivity("farai", grouped).All(ctx)
+
+	if len(items) != 1 || items[0].Group == nil || !cmp.Equal(items[0].Group.Actors, []string{"tendai", "rudo"}) {
+		t.Errorf("got %v want the likes grouped", itemValues(items))
+	}
+
+	if results, err := NewPublisher(NewMemoryGraph(), feed).Publish(ctx, "nobody", Action{Value: "hello"}); err != nil || len(results) != 0 {
+		t.Errorf("got %v, %v want nothing published", results, err)
+	}
+}
+
+// changing is a graph whose followers change once the first page of them is read
+type changing struct {
+	Graph
+	change func(graph Graph)
+}
+
+func (c changing) FollowersAfter(ctx context.Context, user string, after Follow, limit int) ([]Follow, error) {
+	follows, err := c.Graph.FollowersAfter(ctx, user, after, limit)
+
+	if after.IsZero() {
+		c.change(c.Graph)
+	}
+
+	return follows, err
+}
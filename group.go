@@ -0,0 +1,65 @@
+package feeder
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Group is a set of actions sharing a method and object that were stored within the
+// GroupWindow of a feed, such as the likes of a post
+type Group struct {
+	// Actors are the actors of the group, the latest first and each once
+	Actors []string `json:"actors"`
+	// Count is the number of actions in the group
+	Count int `json:"count"`
+	// First is when the first action of the group happened, the item of the group is at the latest
+	First time.Time `json:"first"`
+}
+
+// groupState is a group as it is stored next to the events of a user, the scripts of the
+// Redis backend read and write it as JSON too
+type groupState struct {
+	Key    string   `json:"key"`
+	First  float64  `json:"first"`
+	Count  int      `json:"count"`
+	Actors []string `json:"actors,omitempty"`
+}
+
+// encodeGroup encodes the state of a group as JSON
+func encodeGroup(g groupState) string {
+	data, _ := json.Marshal(g)
+	return string(data)
+}
+
+// groupKey is the key actions are grouped by, their method and object
+func groupKey(action Action) string {
+	return action.Method + "\x00" + action.Object
+}
+
+// windowSeconds is the group window of a feed in seconds, events are scored by the second
+func windowSeconds(window time.Duration) int64 {
+	return int64(math.Ceil(window.Seconds()))
+}
+
+// withGroup sets the group of an item from its stored state, items without a state are left as they are
+func (i Item) withGroup(state string) (Item, error) {
+	if state == "" {
+		return i, nil
+	}
+
+	var g groupState
+
+	if err := json.Unmarshal([]byte(state), &g); err != nil {
+		return i, fmt.Errorf("%w: group %s %q", ErrCorruptMeta, i.ID, state)
+	}
+
+	i.Group = &Group{
+		Actors: g.Actors,
+		Count:  g.Count,
+		First:  time.Unix(int64(g.First), 0),
+	}
+
+	return i, nil
+}
@@ -0,0 +1,51 @@
+package feeder
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+)
+
+// crockford is the alphabet IDs are encoded in, it leaves out I, L, O and U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// idSeq numbers the IDs made by the process, it takes the high bits of the random part of an ID
+// so IDs of the same millisecond sort in the order they were made whatever other times IDs were
+// made for in between
+var idSeq atomic.Uint64
+
+// NewID returns a sortable unique ID for an event that happened at t. IDs are ULIDs: 48 bits of
+// milliseconds followed by 80 bits in 26 characters of Crockford's base32, so they sort by time.
+// The 80 bits are a 40 bit sequence number of the process followed by 40 random bits, so IDs
+// made by a process for the same millisecond increase in the order they were made
+func NewID(t time.Time) string {
+	var ms uint64
+	if t.UnixMilli() > 0 {
+		ms = uint64(t.UnixMilli())
+	}
+
+	var id [16]byte
+	if _, err := rand.Read(id[11:]); err != nil {
+		panic("feeder: reading random bits for an ID: " + err.Error())
+	}
+
+	seq := idSeq.Add(1)
+
+	binary.BigEndian.PutUint16(id[0:], uint16(ms>>32))
+	binary.BigEndian.PutUint32(id[2:], uint32(ms))
+	id[6] = byte(seq >> 32)
+	binary.BigEndian.PutUint32(id[7:], uint32(seq))
+
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+
+	var s [26]byte
+	for i := len(s) - 1; i >= 0; i-- {
+		s[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(s[:])
+}
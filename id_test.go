@@ -0,0 +1,59 @@
+package feeder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewID(t *testing.T) {
+
+	at := time.Unix(1700000000, 0)
+
+	first := NewID(at)
+
+	if len(first) != 26 {
+		t.Errorf("got an ID of %d characters want %d", len(first), 26)
+	}
+
+	// IDs of the same time increase in the order they are made
+	last := first
+	for i := 0; i < 1000; i++ {
+		id := NewID(at)
+		if id <= last {
+			t.Errorf("got %s after %s want increasing IDs", id, last)
+			return
+		}
+		last = id
+	}
+
+	if later := NewID(at.Add(time.Millisecond)); later <= last {
+		t.Errorf("got %s after %s want IDs to sort by time", later, last)
+	}
+
+	if earlier := NewID(at.Add(-time.Second)); earlier >= first {
+		t.Errorf("got %s before %s want IDs to sort by time", earlier, first)
+	}
+
+	if got, want := NewID(at)[:10], first[:10]; got != want {
+		t.Errorf("got time %s want %s", got, want)
+	}
+}
+
+func TestNewIDInterleaved(t *testing.T) {
+
+	at := time.Unix(1700000000, 0)
+	other := time.Unix(1600000000, 0)
+
+	// IDs of other times made in between keep the IDs of one time in order
+	last := NewID(at)
+	for i := 0; i < 1000; i++ {
+		NewID(other.Add(time.Duration(i) * time.Millisecond))
+
+		id := NewID(at)
+		if id <= last {
+			t.Errorf("got %s after %s want increasing IDs", id, last)
+			return
+		}
+		last = id
+	}
+}
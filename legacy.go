@@ -0,0 +1,246 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// legacy adapts a LegacyBackend to the Backend interface
+type legacy struct {
+	b    LegacyBackend
+	feed string
+	size int
+}
+
+// FromLegacy adapts a backend written before calls took a context. A LegacyBackend cannot
+// stop a call once it has started, so the context is only checked before each call is made.
+// A LegacyBackend knows nothing of feeds, the adapter keeps the users of each feed apart and
+// trims them to the size of the feed itself
+func FromLegacy(b LegacyBackend) Backend {
+	return legacy{b: b}
+}
+
+// WithFeed scopes the wrapped backend to a feed
+func (l legacy) WithFeed(name string, size int) Backend {
+	l.feed = name
+	l.size = size
+	return l
+}
+
+// user namespaces a user of the wrapped backend by the name of the feed
+func (l legacy) user(user string) string {
+	return key("", l.feed, user)
+}
+
+// fail wraps an error with the operation, feed and user it happened on. An OpError of the
+// wrapped backend names the user the adapter namespaced, so only its cause is kept
+func (l legacy) fail(op, user string, err error) error {
+	var opErr *OpError
+	if errors.As(err, &opErr) {
+		err = opErr.Err
+	}
+	return opError(op, l.feed, user, err)
+}
+
+// HealthCheck checks the wrapped backend
+func (l legacy) HealthCheck(ctx context.Context) (string, error) {
+	if err := contextError(ctx); err != nil {
+		return "", l.fail("health check", "", err)
+	}
+	res, err := l.b.HealthCheck()
+	return res, l.fail("health check", "", err)
+}
+
+// Store stores an event for a user
+func (l legacy) Store(ctx context.Context, user, value string, at int64) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, l.fail("store", user, err)
+	}
+	res, err := l.b.Store(l.user(user), value, at)
+
+	if err != nil {
+		return res, l.fail("store", user, err)
+	}
+
+	removed, err := l.trim(user)
+	return res - removed, l.fail("store", user, err)
+}
+
+// trim removes the oldest events of a user past the size of the feed and returns how many
+func (l legacy) trim(user string) (int64, error) {
+	count, err := l.b.Count(l.user(user))
+
+	if err != nil || count <= feedSize(l.size) {
+		return 0, err
+	}
+
+	values, err := l.b.All(l.user(user))
+
+	if err != nil || len(values) <= feedSize(l.size) {
+		return 0, err
+	}
+
+	var removed int64
+
+	for _, value := range values[feedSize(l.size):] {
+		res, err := l.b.Delete(l.user(user), value, 0)
+
+		if err != nil {
+			return removed, err
+		}
+
+		removed += res
+	}
+
+	return removed, nil
+}
+
+// StoreID stores an event for a user, a legacy backend keys events by value so the id is not kept.
+// Legacy backends order events by time, so only events scored by their time are supported
+func (l legacy) StoreID(ctx context.Context, user, id, value string, at int64, score float64) (int64, error) {
+	if score != float64(at) {
+		return 0, l.fail("store", user, ErrNotSupported)
+	}
+	return l.Store(ctx, user, value, at)
+}
+
+// Delete removes an event for a user
+func (l legacy) Delete(ctx context.Context, user, value string, at int64) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, l.fail("delete", user, err)
+	}
+	res, err := l.b.Delete(l.user(user), value, at)
+	return res, l.fail("delete", user, err)
+}
+
+// DeleteID removes the event stored with id as its value, a legacy backend keys events by value
+func (l legacy) DeleteID(ctx context.Context, user, id string) (bool, error) {
+	res, err := l.Delete(ctx, user, id, 0)
+	return res > 0, err
+}
+
+// UnreadCount counts the events since the last read, legacy backends keep no unread count
+func (l legacy) UnreadCount(ctx context.Context, user string) (int64, error) {
+	if _, err := l.Count(ctx, user); err != nil {
+		return 0, err
+	}
+
+	lastRead, err := l.LastRead(ctx, user)
+
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return 0, err
+	}
+
+	return l.UnRead(ctx, user, lastRead)
+}
+
+// RecalculateUnread counts the events since the last read, legacy backends keep no unread count to repair
+func (l legacy) RecalculateUnread(ctx context.Context, user string) (int64, error) {
+	return l.UnreadCount(ctx, user)
+}
+
+// Wipe wipes the users feed
+func (l legacy) Wipe(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, l.fail("wipe", user, err)
+	}
+	res, err := l.b.Wipe(l.user(user))
+	return res, l.fail("wipe", user, err)
+}
+
+// All returns all events for the user
+func (l legacy) All(ctx context.Context, user string) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, l.fail("all", user, err)
+	}
+	res, err := l.b.All(l.user(user))
+	return legacyItems(res), l.fail("all", user, err)
+}
+
+// Paginate paginates events for the user
+func (l legacy) Paginate(ctx context.Context, user string, page, perPage int) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, l.fail("paginate", user, err)
+	}
+	if page < 1 || perPage < 1 {
+		return nil, l.fail("paginate", user, fmt.Errorf("%w: page %d of %d", ErrInvalidPage, page, perPage))
+	}
+	res, err := l.b.Paginate(l.user(user), page, perPage)
+	return legacyItems(res), l.fail("paginate", user, err)
+}
+
+// After is not supported by legacy backends, they do not return when events happened
+func (l legacy) After(ctx context.Context, user string, cursor Cursor, limit int) ([]Item, error) {
+	return nil, l.fail("after", user, ErrNotSupported)
+}
+
+// Before is not supported by legacy backends, they do not return when events happened
+func (l legacy) Before(ctx context.Context, user string, cursor Cursor, limit int) ([]Item, error) {
+	return nil, l.fail("before", user, ErrNotSupported)
+}
+
+// Between is not supported by legacy backends, they do not return when events happened
+func (l legacy) Between(ctx context.Context, user string, from, to int64) ([]Item, error) {
+	return nil, l.fail("between", user, ErrNotSupported)
+}
+
+// Count return the total count of events of user
+func (l legacy) Count(ctx context.Context, user string) (int, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, l.fail("count", user, err)
+	}
+	res, err := l.b.Count(l.user(user))
+	return res, l.fail("count", user, err)
+}
+
+// UnRead returns the total count of un read feed items
+func (l legacy) UnRead(ctx context.Context, user string, at int64) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, l.fail("unread", user, err)
+	}
+	res, err := l.b.UnRead(l.user(user), at)
+	return res, l.fail("unread", user, err)
+}
+
+// ResetLastRead resets last read time stamp
+func (l legacy) ResetLastRead(ctx context.Context, user string, at int64) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, l.fail("reset last read", user, err)
+	}
+	res, err := l.b.ResetLastRead(l.user(user), at)
+	return res, l.fail("reset last read", user, err)
+}
+
+// LastRead is LastRead when feed was last paginated
+func (l legacy) LastRead(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, l.fail("last read", user, err)
+	}
+	res, err := l.b.LastRead(l.user(user))
+	return res, l.fail("last read", user, err)
+}
+
+// RecalculateCount count recalculates the length of events
+func (l legacy) RecalculateCount(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, l.fail("recalculate count", user, err)
+	}
+	res, err := l.b.RecalculateCount(l.user(user))
+	return res, l.fail("recalculate count", user, err)
+}
+
+// legacyItems makes items of the events of a legacy backend, which does not return
+// when they happened so they are left without a time and unread flag
+func legacyItems(values []string) []Item {
+	if values == nil {
+		return nil
+	}
+
+	items := make([]Item, len(values))
+	for i, value := range values {
+		items[i] = Item{ID: value, Value: value}
+	}
+
+	return items
+}
@@ -0,0 +1,240 @@
+package feeder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FeedsConfig is a configuration file describing the feeds of an application,
+// see golden/feeds.golden.json
+type FeedsConfig struct {
+	Feeds []FeedConfig `json:"feeds" yaml:"feeds"`
+}
+
+// FeedConfig describes a feed and the backend it is stored in
+type FeedConfig struct {
+	Name        string         `json:"name" yaml:"name"`
+	Size        int            `json:"max_size" yaml:"max_size"`
+	PerPage     int            `json:"per_page" yaml:"per_page"`
+	Codec       string         `json:"codec" yaml:"codec"`
+	Dedupe      bool           `json:"dedupe" yaml:"dedupe"`
+	GroupWindow string         `json:"group_window" yaml:"group_window"` // a duration such as 1h
+	CursorKey   string         `json:"cursor_key" yaml:"cursor_key"`     // signs cursors, share it between instances
+	Provider    ProviderConfig `json:"provider" yaml:"provider"`
+}
+
+// ProviderConfig describes the backend of a feed
+type ProviderConfig struct {
+	Engine    string            `json:"engine" yaml:"engine"`
+	Arguments ProviderArguments `json:"arguments" yaml:"arguments"`
+}
+
+// ProviderArguments are the connection arguments of a backend
+type ProviderArguments struct {
+	URL      string `json:"url" yaml:"url"`
+	Host     string `json:"host" yaml:"host"`
+	Port     int    `json:"port" yaml:"port"`
+	Pool     int    `json:"pool" yaml:"pool"`
+	Db       int    `json:"db" yaml:"db"`
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	TLS      bool   `json:"tls" yaml:"tls"`
+}
+
+// Config converts the provider into a backend configuration, unset arguments take the defaults of Config
+func (p ProviderConfig) Config() Config {
+	config := Config{
+		Engine:   p.Engine,
+		URL:      p.Arguments.URL,
+		Host:     p.Arguments.Host,
+		Port:     strconv.Itoa(p.Arguments.Port),
+		Db:       p.Arguments.Db,
+		Pool:     p.Arguments.Pool,
+		Username: p.Arguments.Username,
+		Password: p.Arguments.Password,
+		TLS:      p.Arguments.TLS,
+	}
+
+	if config.Engine == "" {
+		config.Engine = "redis"
+	}
+	if config.Host == "" {
+		config.Host = "localhost"
+	}
+	if p.Arguments.Port == 0 {
+		config.Port = "6379"
+	}
+	if config.Pool == 0 {
+		config.Pool = 10
+	}
+
+	return config
+}
+
+// Validate reports every problem in the configuration
+func (c FeedsConfig) Validate() error {
+	var errs []error
+
+	if len(c.Feeds) == 0 {
+		errs = append(errs, errors.New("no feeds configured"))
+	}
+
+	names := map[string]bool{}
+
+	for i, feed := range c.Feeds {
+		switch {
+		case feed.Name == "":
+			errs = append(errs, fmt.Errorf("feed %d has no name", i))
+		case names[feed.Name]:
+			errs = append(errs, fmt.Errorf("feed %s is configured more than once", feed.Name))
+		}
+		names[feed.Name] = true
+
+		if feed.Size < 0 {
+			errs = append(errs, fmt.Errorf("feed %s has a negative max_size %d", feed.Name, feed.Size))
+		}
+		if feed.PerPage < 0 {
+			errs = append(errs, fmt.Errorf("feed %s has a negative per_page %d", feed.Name, feed.PerPage))
+		}
+
+		if _, ok := LookupCodec(feed.Codec); feed.Codec != "" && !ok {
+			errs = append(errs, fmt.Errorf("feed %s has an unknown codec %q", feed.Name, feed.Codec))
+		}
+
+		if window, err := time.ParseDuration(feed.GroupWindow); feed.GroupWindow != "" && (err != nil || window < 0) {
+			errs = append(errs, fmt.Errorf("feed %s has an invalid group_window %q", feed.Name, feed.GroupWindow))
+		}
+
+		if engine := feed.Provider.Config().Engine; !registered(engine) {
+			errs = append(errs, fmt.Errorf("feed %s: %w %q", feed.Name, ErrUnknownEngine, engine))
+		}
+
+		args := feed.Provider.Arguments
+		if args.Port < 0 || args.Port > 65535 {
+			errs = append(errs, fmt.Errorf("feed %s has an invalid port %d", feed.Name, args.Port))
+		}
+		if args.Pool < 0 {
+			errs = append(errs, fmt.Errorf("feed %s has a negative pool %d", feed.Name, args.Pool))
+		}
+		if args.Db < 0 {
+			errs = append(errs, fmt.Errorf("feed %s has a negative db %d", feed.Name, args.Db))
+		}
+		if args.URL != "" {
+			if _, err := ParseURL(args.URL); err != nil {
+				errs = append(errs, fmt.Errorf("feed %s: %w", feed.Name, err))
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	return nil
+}
+
+// Registry validates the configuration, connects the backends and registers every feed.
+// Feeds whose providers have the same arguments share a backend
+func (c FeedsConfig) Registry() (*Registry, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	registry := NewRegistry()
+	backends := map[Config]Backend{}
+
+	for _, feed := range c.Feeds {
+		config := feed.Provider.Config()
+
+		backend, ok := backends[config]
+		if !ok {
+			var err error
+			if backend, err = Open(config); err != nil {
+				closeBackends(backends)
+				return nil, fmt.Errorf("feed %s: %w", feed.Name, err)
+			}
+			backends[config] = backend
+		}
+
+		f := NewFeed(feed.Name, feed.Size, feed.PerPage, backend)
+		f.Codec, _ = LookupCodec(feed.Codec)
+		f.Dedupe = feed.Dedupe
+		f.GroupWindow, _ = time.ParseDuration(feed.GroupWindow)
+
+		if feed.CursorKey != "" {
+			f.CursorKey = []byte(feed.CursorKey)
+		}
+
+		if err := registry.Register(f); err != nil {
+			closeBackends(backends)
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+// closeBackends closes the backends opened for a configuration that failed to load, the
+// backends that hold no connections have nothing to close
+func closeBackends(backends map[Config]Backend) {
+	for _, backend := range backends {
+		if closer, ok := backend.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// ParseFeedsConfig decodes a configuration in format, which is either "json" or "yaml"
+func ParseFeedsConfig(data []byte, format string) (FeedsConfig, error) {
+	var config FeedsConfig
+	var err error
+
+	switch format {
+	case "json":
+		err = json.Unmarshal(data, &config)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &config)
+	default:
+		return config, fmt.Errorf("%w: unknown format %q", ErrInvalidConfig, format)
+	}
+
+	if err != nil {
+		return config, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	return config, nil
+}
+
+// LoadFeeds reads a JSON or YAML configuration file, picked by its extension,
+// and returns a registry holding its feeds
+func LoadFeeds(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	format := filepath.Ext(path)
+	if format != "" {
+		format = format[1:]
+	}
+
+	config, err := ParseFeedsConfig(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	registry, err := config.Registry()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return registry, nil
+}
@@ -0,0 +1,208 @@
+package feeder
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadFeeds(t *testing.T) {
+
+	tt := []struct {
+		description string
+		path        string
+		want        []string
+	}{
+		{description: "loads a json file", path: "golden/feeds.golden.json", want: []string{"news", "notifications"}},
+		{description: "loads a yaml file", path: "golden/feeds.golden.yaml", want: []string{"news", "notifications", "previews"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			registry, err := LoadFeeds(tc.path)
+
+			if err != nil {
+				t.Errorf("load error %s", err)
+				return
+			}
+
+			feeds := registry.Feeds()
+
+			if len(feeds) != len(tc.want) {
+				t.Errorf("got %d feeds want %d", len(feeds), len(tc.want))
+				return
+			}
+
+			for i, feed := range feeds {
+				if feed.Name != tc.want[i] {
+					t.Errorf("got feed %s want %s", feed.Name, tc.want[i])
+				}
+			}
+
+			news, _ := registry.Feed("news")
+			notifications, _ := registry.Feed("notifications")
+
+			if news.Size != 5 || news.PerPage != 2 {
+				t.Errorf("news configured with size %d and per page %d want 5 and 2", news.Size, news.PerPage)
+			}
+
+			client, ok := news.P.(Redis)
+
+			if !ok {
+				t.Errorf("news should be stored in redis got %T", news.P)
+				return
+			}
+
+			if client.C != notifications.P.(Redis).C {
+				t.Errorf("feeds with the same provider arguments should share a connection")
+			}
+
+			if news.codec() != JSON {
+				t.Errorf("feeds without a codec should store json got %s", news.codec().Name())
+			}
+
+			if previews, err := registry.Feed("previews"); err == nil && previews.codec() != MessagePack {
+				t.Errorf("previews configured with the %s codec want msgpack", previews.codec().Name())
+			}
+
+			if previews, err := registry.Feed("previews"); err == nil && (!previews.Dedupe || news.Dedupe) {
+				t.Errorf("only previews are configured to dedupe")
+			}
+
+			if tc.path == "golden/feeds.golden.yaml" && notifications.GroupWindow != time.Hour {
+				t.Errorf("notifications grouped within %s want %s", notifications.GroupWindow, time.Hour)
+			}
+
+			if tc.path == "golden/feeds.golden.yaml" && (string(news.CursorKey) != "6f7e2b1c9d4a8e3f" || notifications.CursorKey != nil) {
+				t.Errorf("only news is configured with a cursor key got %q", news.CursorKey)
+			}
+
+			if opt := client.C.Options(); opt.Addr != "127.0.0.1:6739" || opt.DB != 2 || opt.PoolSize != 4 {
+				t.Errorf("connection configured with %s db %d pool %d", opt.Addr, opt.DB, opt.PoolSize)
+			}
+
+		})
+	}
+
+}
+
+func TestFeedsConfigValidate(t *testing.T) {
+
+	tt := []struct {
+		description string
+		config      string
+		want        error
+	}{
+		{
+			description: "accepts a feed with default provider arguments",
+			config:      `{"feeds": [{"name": "news", "max_size": 5, "per_page": 2, "provider": {"engine": "memory"}}]}`,
+			want:        nil,
+		},
+		{
+			description: "rejects a file without feeds",
+			config:      `{"feeds": []}`,
+			want:        ErrInvalidConfig,
+		},
+		{
+			description: "rejects a feed without a name",
+			config:      `{"feeds": [{"max_size": 5}]}`,
+			want:        ErrInvalidConfig,
+		},
+		{
+			description: "rejects duplicate feeds",
+			config:      `{"feeds": [{"name": "news"}, {"name": "news"}]}`,
+			want:        ErrInvalidConfig,
+		},
+		{
+			description: "rejects negative sizes",
+			config:      `{"feeds": [{"name": "news", "max_size": -1, "per_page": -2}]}`,
+			want:        ErrInvalidConfig,
+		},
+		{
+			description: "rejects an invalid port",
+			config:      `{"feeds": [{"name": "news", "provider": {"arguments": {"port": 70000}}}]}`,
+			want:        ErrInvalidConfig,
+		},
+		{
+			description: "rejects an unknown engine",
+			config:      `{"feeds": [{"name": "news", "provider": {"engine": "cassandra"}}]}`,
+			want:        ErrInvalidConfig,
+		},
+		{
+			description: "rejects an unknown codec",
+			config:      `{"feeds": [{"name": "news", "codec": "protobuf", "provider": {"engine": "memory"}}]}`,
+			want:        ErrInvalidConfig,
+		},
+		{
+			description: "rejects an invalid group window",
+			config:      `{"feeds": [{"name": "news", "group_window": "an hour", "provider": {"engine": "memory"}}]}`,
+			want:        ErrInvalidConfig,
+		},
+		{
+			description: "rejects malformed json",
+			config:      `{"feeds": [`,
+			want:        ErrInvalidConfig,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			config, err := ParseFeedsConfig([]byte(tc.config), "json")
+
+			if err == nil {
+				_, err = config.Registry()
+			}
+
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got %v want %v", err, tc.want)
+			}
+
+		})
+	}
+
+}
+
+// closing is a backend that records when it is closed
+type closing struct {
+	Backend
+	closed *int
+}
+
+func (c closing) Close() error {
+	*c.closed++
+	return nil
+}
+
+// closed counts the backends of the closing driver that were closed
+var closed int
+
+func init() {
+	RegisterDriver("closing", DriverFunc(func(config Config) (Backend, error) {
+		if config.Host == "down" {
+			return nil, errors.New("connection refused")
+		}
+		return closing{Backend: NewMemoryBackend(), closed: &closed}, nil
+	}))
+}
+
+func TestFeedsConfigRegistryCloses(t *testing.T) {
+
+	closed = 0
+
+	config := FeedsConfig{Feeds: []FeedConfig{
+		{Name: "news", Provider: ProviderConfig{Engine: "closing", Arguments: ProviderArguments{Host: "up"}}},
+		{Name: "alerts", Provider: ProviderConfig{Engine: "closing", Arguments: ProviderArguments{Host: "up", Db: 1}}},
+		{Name: "mentions", Provider: ProviderConfig{Engine: "closing", Arguments: ProviderArguments{Host: "down"}}},
+	}}
+
+	if _, err := config.Registry(); err == nil {
+		t.Errorf("got a registry want the error of the backend that is down")
+	}
+
+	if closed != 2 {
+		t.Errorf("closed %d backends want the %d opened before the error", closed, 2)
+	}
+
+}
@@ -0,0 +1,1134 @@
+package feeder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterDriver("memory", DriverFunc(func(config Config) (Backend, error) {
+		return NewMemoryBackend(), nil
+	}))
+}
+
+// MemoryBackend is an in-memory implementation of the Backend interface.
+// It mirrors the data layout of the Redis backend (a sorted set, a meta hash and the payload, flags,
+// groups, times, pins and priorities hashes per user)
+// so the two behave the same, and is safe for concurrent use
+type MemoryBackend struct {
+	s *memoryStore
+	// Prefix is prepended to every key like it is for the Redis backend
+	Prefix string
+	feed   string
+	size   int
+}
+
+// memoryStore holds the sorted sets and hashes shared by every copy of a MemoryBackend
+type memoryStore struct {
+	mu     sync.RWMutex
+	sets   map[string][]memoryMember
+	hashes map[string]map[string]int64
+	values map[string]map[string]string
+}
+
+// memoryMember is a sorted set member and its score
+type memoryMember struct {
+	member string
+	score  float64
+}
+
+// less orders members the way Redis does, by score then lexicographically
+func (m memoryMember) less(o memoryMember) bool {
+	if m.score != o.score {
+		return m.score < o.score
+	}
+	return m.member < o.member
+}
+
+// WithFeed returns a copy of the backend scoped to a feed, sharing the same store
+func (m MemoryBackend) WithFeed(name string, size int) Backend {
+	m.feed = name
+	m.size = size
+	return m
+}
+
+// dataKey is the sorted set holding the users events
+func (m MemoryBackend) dataKey(user string) string {
+	return key(m.Prefix, m.feed, user) + ".data"
+}
+
+// metaKey is the hash holding the users counters and last read time stamp
+func (m MemoryBackend) metaKey(user string) string {
+	return key(m.Prefix, m.feed, user) + ".meta"
+}
+
+// payloadKey is the hash holding the values of the users events by ID
+func (m MemoryBackend) payloadKey(user string) string {
+	return key(m.Prefix, m.feed, user) + ".payload"
+}
+
+// flagsKey is the hash holding the read flags of the users events by ID
+func (m MemoryBackend) flagsKey(user string) string {
+	return key(m.Prefix, m.feed, user) + ".flags"
+}
+
+// groupsKey is the hash holding the groups of the users aggregated events
+func (m MemoryBackend) groupsKey(user string) string {
+	return key(m.Prefix, m.feed, user) + ".groups"
+}
+
+// timesKey is the hash holding the times of the users ranked events by ID
+func (m MemoryBackend) timesKey(user string) string {
+	return key(m.Prefix, m.feed, user) + ".times"
+}
+
+// pinsKey is the hash holding the time the users pinned events are pinned until by ID
+func (m MemoryBackend) pinsKey(user string) string {
+	return key(m.Prefix, m.feed, user) + ".pins"
+}
+
+// prioritiesKey is the hash holding the priorities of the users events by ID
+func (m MemoryBackend) prioritiesKey(user string) string {
+	return key(m.Prefix, m.feed, user) + ".priorities"
+}
+
+// fail wraps an error with the operation, feed and user it happened on
+func (m MemoryBackend) fail(op, user string, err error) error {
+	return opError(op, m.feed, user, err)
+}
+
+// HealthCheck always succeeds for the in-memory backend unless ctx is done
+func (m MemoryBackend) HealthCheck(ctx context.Context) (string, error) {
+	if err := contextError(ctx); err != nil {
+		return "", m.fail("health check", "", err)
+	}
+
+	return "PONG", nil
+}
+
+// Store stores an event for a user, the value is its own ID
+func (m MemoryBackend) Store(ctx context.Context, user, value string, at int64) (int64, error) {
+	return m.StoreID(ctx, user, value, value, at, float64(at))
+}
+
+// StoreID stores an event for a user under id scored score
+func (m MemoryBackend) StoreID(ctx context.Context, user, id, value string, at int64, score float64) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, m.fail("store", user, err)
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	return m.store(user, id, value, at, score), nil
+}
+
+// StoreGroup adds an event to the group open for key when the group started within window
+// seconds, otherwise it stores the event under id as a new group
+func (m MemoryBackend) StoreGroup(ctx context.Context, user, id, value string, at int64, score float64, key, actor string, window int64) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, m.fail("store", user, err)
+	}
+
+	userGroups := m.groupsKey(user)
+	open := "open:" + key
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if gid, ok := m.s.values[userGroups][open]; ok {
+		latest, stored := m.s.zscore(m.dataKey(user), gid)
+
+		var group groupState
+		err := json.Unmarshal([]byte(m.s.values[userGroups][gid]), &group)
+
+		if stored && err == nil && float64(at)-group.First <= float64(window) {
+			actors := []string{}
+			if actor != "" {
+				actors = append(actors, actor)
+			}
+			for _, a := range group.Actors {
+				if a != actor {
+					actors = append(actors, a)
+				}
+			}
+
+			group.Actors = actors
+			group.Count++
+			m.s.hsetValue(userGroups, gid, encodeGroup(group))
+
+			if float64(at) >= m.at(user, gid, latest) {
+				_, unreadChange := m.add(user, gid, value, at, score)
+				m.count(user, 0, unreadChange)
+			}
+
+			return 0, nil
+		}
+	}
+
+	group := groupState{Key: key, First: float64(at), Count: 1}
+	if actor != "" {
+		group.Actors = []string{actor}
+	}
+
+	m.s.hsetValue(userGroups, open, id)
+	m.s.hsetValue(userGroups, id, encodeGroup(group))
+
+	return m.store(user, id, value, at, score), nil
+}
+
+// store stores an event, trims the feed and moves the counters, the store must be locked
+func (m MemoryBackend) store(user, id, value string, at int64, score float64) int64 {
+	added, unreadChange := m.add(user, id, value, at, score)
+	removed, trimmedUnread := m.trim(user)
+
+	change := added - removed
+	m.count(user, change, unreadChange+trimmedUnread)
+
+	return change
+}
+
+// add stores an event scored score that happened at and returns 1 when it is new along with the
+// change to the unread count, the store must be locked
+func (m MemoryBackend) add(user, id, value string, at int64, score float64) (int64, int64) {
+	userData := m.dataKey(user)
+
+	var unreadChange int64
+	if old, ok := m.s.zscore(userData, id); ok {
+		unreadChange = -m.unread(user, id, old)
+	}
+
+	if id != value {
+		m.s.hsetValue(m.payloadKey(user), id, value)
+	} else {
+		m.s.hdelValues(m.payloadKey(user), id)
+	}
+	m.s.hdelValues(m.flagsKey(user), id)
+
+	if float64(at) != score {
+		m.s.hsetValue(m.timesKey(user), id, strconv.FormatInt(at, 10))
+	} else {
+		m.s.hdelValues(m.timesKey(user), id)
+	}
+
+	added := m.s.zadd(userData, memoryMember{member: id, score: score})
+
+	return added, unreadChange + m.unread(user, id, score)
+}
+
+// trim trims the feed to its size besides the pinned events, lowest priority and then lowest
+// score first, and returns the number of events removed along with the change to the unread
+// count. Expired pins are dropped, the store must be locked
+func (m MemoryBackend) trim(user string) (int64, int64) {
+	userData := m.dataKey(user)
+	userPins := m.pinsKey(user)
+
+	pins := m.pinned(user, time.Now().Unix())
+	for id := range m.s.values[userPins] {
+		if !pins[id] {
+			m.s.hdelValues(userPins, id)
+		}
+	}
+
+	candidates := []memoryMember{}
+	for _, e := range m.s.sets[userData] {
+		if !pins[e.member] {
+			candidates = append(candidates, e)
+		}
+	}
+
+	excess := len(candidates) - feedSize(m.size)
+	if excess <= 0 {
+		return 0, 0
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return m.priority(user, candidates[i].member) < m.priority(user, candidates[j].member)
+	})
+
+	var unreadChange int64
+	for _, e := range candidates[:excess] {
+		unreadChange -= m.unread(user, e.member, e.score)
+		m.s.zrem(userData, e.member)
+		m.forget(user, e.member)
+	}
+
+	return int64(excess), unreadChange
+}
+
+// pinned returns the IDs of the users events pinned at now as a set, the store must be locked
+func (m MemoryBackend) pinned(user string, now int64) map[string]bool {
+	pins := map[string]bool{}
+
+	for id, pin := range m.s.values[m.pinsKey(user)] {
+		// the pins are written by the backend itself so they always parse
+		if until, _ := strconv.ParseInt(pin, 10, 64); until == 0 || until > now {
+			pins[id] = true
+		}
+	}
+
+	return pins
+}
+
+// priority returns the priority of the event id of the user, the store must be locked
+func (m MemoryBackend) priority(user, id string) int {
+	priority, _ := strconv.Atoi(m.s.values[m.prioritiesKey(user)][id])
+	return priority
+}
+
+// ranked returns the events of the user newest first after the events pinned at now,
+// the store must be locked
+func (m MemoryBackend) ranked(user string, now int64) []memoryMember {
+	members := m.s.zrevrange(m.dataKey(user), 0, -1)
+
+	pins := m.pinned(user, now)
+	if len(pins) == 0 {
+		return members
+	}
+
+	ranked := make([]memoryMember, 0, len(members))
+	for _, e := range members {
+		if pins[e.member] {
+			ranked = append(ranked, e)
+		}
+	}
+	for _, e := range members {
+		if !pins[e.member] {
+			ranked = append(ranked, e)
+		}
+	}
+
+	return ranked
+}
+
+// forget removes the payload, flag, group, time, pin and priority of an event, the store must be locked
+func (m MemoryBackend) forget(user, id string) {
+	userGroups := m.groupsKey(user)
+
+	if state, ok := m.s.values[userGroups][id]; ok {
+		var group groupState
+		if json.Unmarshal([]byte(state), &group) == nil && m.s.values[userGroups]["open:"+group.Key] == id {
+			m.s.hdelValues(userGroups, "open:"+group.Key)
+		}
+		m.s.hdelValues(userGroups, id)
+	}
+
+	m.s.hdelValues(m.payloadKey(user), id)
+	m.s.hdelValues(m.flagsKey(user), id)
+	m.s.hdelValues(m.timesKey(user), id)
+	m.s.hdelValues(m.pinsKey(user), id)
+	m.s.hdelValues(m.prioritiesKey(user), id)
+}
+
+// count moves the counters of the user, the store must be locked
+func (m MemoryBackend) count(user string, change, unreadChange int64) {
+	m.s.hincrBy(m.metaKey(user), "total_count", change)
+	m.s.hincrBy(m.metaKey(user), "unread_count", unreadChange)
+}
+
+// Delete removes the event stored with value as its ID and updates the counters
+func (m MemoryBackend) Delete(ctx context.Context, user, value string, at int64) (int64, error) {
+	deleted, err := m.DeleteID(ctx, user, value)
+	if deleted {
+		return 1, err
+	}
+	return 0, err
+}
+
+// DeleteID removes an event and updates the counters, reporting whether the event existed
+func (m MemoryBackend) DeleteID(ctx context.Context, user, id string) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, m.fail("delete", user, err)
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	score, ok := m.s.zscore(m.dataKey(user), id)
+	if !ok {
+		return false, nil
+	}
+
+	unreadChange := -m.unread(user, id, score)
+
+	m.s.zrem(m.dataKey(user), id)
+	m.forget(user, id)
+	m.count(user, -1, unreadChange)
+
+	return true, nil
+}
+
+// Update replaces the value of an event in place, reporting whether the event existed
+func (m MemoryBackend) Update(ctx context.Context, user, id, value string) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, m.fail("update", user, err)
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.zscore(m.dataKey(user), id); !ok {
+		return false, nil
+	}
+
+	if id == value {
+		m.s.hdelValues(m.payloadKey(user), id)
+	} else {
+		m.s.hsetValue(m.payloadKey(user), id, value)
+	}
+
+	return true, nil
+}
+
+// Move moves an event to a new time and updates the unread count, reporting whether the event existed
+func (m MemoryBackend) Move(ctx context.Context, user, id string, at int64) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, m.fail("move", user, err)
+	}
+
+	userData := m.dataKey(user)
+	userMeta := m.metaKey(user)
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	score, ok := m.s.zscore(userData, id)
+	if !ok {
+		return false, nil
+	}
+
+	userTimes := m.timesKey(user)
+	before := m.unread(user, id, score)
+
+	// events scored by their time are rescored, ranked events keep their score
+	if _, ranked := m.s.values[userTimes][id]; !ranked {
+		score = float64(at)
+		m.s.zadd(userData, memoryMember{member: id, score: score})
+	} else if float64(at) == score {
+		m.s.hdelValues(userTimes, id)
+	} else {
+		m.s.hsetValue(userTimes, id, strconv.FormatInt(at, 10))
+	}
+
+	m.s.hincrBy(userMeta, "unread_count", m.unread(user, id, score)-before)
+
+	return true, nil
+}
+
+// Pin pins an event until the time stamp until, 0 pins it until it is unpinned. Reports whether the event existed
+func (m MemoryBackend) Pin(ctx context.Context, user, id string, until int64) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, m.fail("pin", user, err)
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.zscore(m.dataKey(user), id); !ok {
+		return false, nil
+	}
+
+	m.s.hsetValue(m.pinsKey(user), id, strconv.FormatInt(until, 10))
+
+	return true, nil
+}
+
+// Unpin unpins an event, reporting whether the event existed. The feed is trimmed back to its
+// size by the next store
+func (m MemoryBackend) Unpin(ctx context.Context, user, id string) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, m.fail("unpin", user, err)
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.zscore(m.dataKey(user), id); !ok {
+		return false, nil
+	}
+
+	m.s.hdelValues(m.pinsKey(user), id)
+
+	return true, nil
+}
+
+// SetPriority sets the priority of an event, reporting whether the event existed
+func (m MemoryBackend) SetPriority(ctx context.Context, user, id string, priority int) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, m.fail("set priority", user, err)
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.zscore(m.dataKey(user), id); !ok {
+		return false, nil
+	}
+
+	if priority == 0 {
+		m.s.hdelValues(m.prioritiesKey(user), id)
+	} else {
+		m.s.hsetValue(m.prioritiesKey(user), id, strconv.Itoa(priority))
+	}
+
+	return true, nil
+}
+
+// Rescore scores events anew by their ID and returns the number of events rescored
+func (m MemoryBackend) Rescore(ctx context.Context, user string, scores map[string]float64) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, m.fail("rescore", user, err)
+	}
+
+	userData := m.dataKey(user)
+	userTimes := m.timesKey(user)
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	var rescored int64
+	for id, score := range scores {
+		old, ok := m.s.zscore(userData, id)
+		if !ok {
+			continue
+		}
+
+		at := m.at(user, id, old)
+		m.s.zadd(userData, memoryMember{member: id, score: score})
+
+		if at == score {
+			m.s.hdelValues(userTimes, id)
+		} else {
+			m.s.hsetValue(userTimes, id, strconv.FormatFloat(at, 'f', -1, 64))
+		}
+
+		rescored++
+	}
+
+	return rescored, nil
+}
+
+// Wipe wipes the users feed and meta and returns the number of events removed
+func (m MemoryBackend) Wipe(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, m.fail("wipe", user, err)
+	}
+
+	userData := m.dataKey(user)
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	count := int64(len(m.s.sets[userData]))
+	delete(m.s.sets, userData)
+	delete(m.s.hashes, m.metaKey(user))
+	delete(m.s.values, m.payloadKey(user))
+	delete(m.s.values, m.flagsKey(user))
+	delete(m.s.values, m.groupsKey(user))
+	delete(m.s.values, m.timesKey(user))
+	delete(m.s.values, m.pinsKey(user))
+	delete(m.s.values, m.prioritiesKey(user))
+
+	return count, nil
+}
+
+// Paginate paginates events for the user, pages start at one
+func (m MemoryBackend) Paginate(ctx context.Context, user string, page, perPage int) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, m.fail("paginate", user, err)
+	}
+
+	if page < 1 || perPage < 1 {
+		return nil, m.fail("paginate", user, fmt.Errorf("%w: page %d of %d", ErrInvalidPage, page, perPage))
+	}
+
+	from := (page - 1) * perPage
+	to := (page * perPage) - 1
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	ranked := m.ranked(user, time.Now().Unix())
+
+	from, to, ok := rankRange(len(ranked), from, to)
+	if !ok {
+		return m.items(user, []memoryMember{}), nil
+	}
+
+	return m.items(user, ranked[from:to+1]), nil
+}
+
+// All returns all events for the user
+func (m MemoryBackend) All(ctx context.Context, user string) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, m.fail("all", user, err)
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	return m.items(user, m.ranked(user, time.Now().Unix())), nil
+}
+
+// After returns up to limit events older than the cursor, newest first
+func (m MemoryBackend) After(ctx context.Context, user string, cursor Cursor, limit int) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, m.fail("after", user, err)
+	}
+
+	if limit < 1 {
+		return nil, m.fail("after", user, fmt.Errorf("%w: limit %d", ErrInvalidPage, limit))
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	set := m.s.sets[m.dataKey(user)]
+	members := []memoryMember{}
+
+	for i := len(set) - 1; i >= 0 && len(members) < limit; i-- {
+		if cursor.IsZero() || cursor.older(set[i].score, set[i].member) {
+			members = append(members, set[i])
+		}
+	}
+
+	return m.items(user, members), nil
+}
+
+// Before returns up to limit events newer than the cursor, newest first
+func (m MemoryBackend) Before(ctx context.Context, user string, cursor Cursor, limit int) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, m.fail("before", user, err)
+	}
+
+	if limit < 1 {
+		return nil, m.fail("before", user, fmt.Errorf("%w: limit %d", ErrInvalidPage, limit))
+	}
+
+	if cursor.IsZero() {
+		return nil, m.fail("before", user, fmt.Errorf("%w: nothing is newer than the newest event", ErrInvalidCursor))
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	set := m.s.sets[m.dataKey(user)]
+	members := []memoryMember{}
+
+	for i := 0; i < len(set) && len(members) < limit; i++ {
+		if cursor.newer(set[i].score, set[i].member) {
+			members = append([]memoryMember{set[i]}, members...)
+		}
+	}
+
+	return m.items(user, members), nil
+}
+
+// Between returns the events that happened at or after from and before to in the order of the feed
+func (m MemoryBackend) Between(ctx context.Context, user string, from, to int64) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, m.fail("between", user, err)
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	set := m.s.sets[m.dataKey(user)]
+	members := []memoryMember{}
+
+	for i := len(set) - 1; i >= 0; i-- {
+		if at := m.at(user, set[i].member, set[i].score); at >= float64(from) && at < float64(to) {
+			members = append(members, set[i])
+		}
+	}
+
+	return m.items(user, members), nil
+}
+
+// items makes the items of the users events, the store must be locked
+func (m MemoryBackend) items(user string, members []memoryMember) []Item {
+	lastRead := m.s.hashes[m.metaKey(user)]["last_read"]
+	values := m.s.values[m.payloadKey(user)]
+	flags := m.s.values[m.flagsKey(user)]
+	groups := m.s.values[m.groupsKey(user)]
+	pins := m.s.values[m.pinsKey(user)]
+	now := time.Now().Unix()
+
+	items := make([]Item, len(members))
+	for i, e := range members {
+		value, ok := values[e.member]
+		if !ok {
+			value = e.member
+		}
+		// the groups are encoded by the backend itself so they always decode
+		items[i], _ = newItem(e.member, value, e.score, m.at(user, e.member, e.score), lastRead).withFlag(flags[e.member]).withGroup(groups[e.member])
+
+		pin, pinned := pins[e.member]
+		until, _ := strconv.ParseInt(pin, 10, 64)
+		items[i] = items[i].withPin(pinned, until, now)
+		items[i].Priority = m.priority(user, e.member)
+	}
+
+	return items
+}
+
+// Count return the total count of events of user
+func (m MemoryBackend) Count(ctx context.Context, user string) (int, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, m.fail("count", user, err)
+	}
+
+	userMeta := m.metaKey(user)
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	count, ok := m.s.hashes[userMeta]["total_count"]
+	if !ok {
+		return 0, m.fail("count", user, ErrUserNotFound)
+	}
+
+	return int(count), nil
+}
+
+// UnreadCount returns the unread count kept in the meta hash
+func (m MemoryBackend) UnreadCount(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, m.fail("unread count", user, err)
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	count, ok := m.s.hashes[m.metaKey(user)]["unread_count"]
+	if !ok {
+		return 0, m.fail("unread count", user, ErrUserNotFound)
+	}
+
+	return count, nil
+}
+
+// RecalculateUnread recounts the unread events from the data set, the flags and the last read
+// time stamp into the unread count and returns it
+func (m MemoryBackend) RecalculateUnread(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, m.fail("recalculate unread", user, err)
+	}
+
+	userMeta := m.metaKey(user)
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.sets[m.dataKey(user)]; !ok {
+		if _, ok := m.s.hashes[userMeta]; !ok {
+			return 0, nil
+		}
+	}
+
+	lastRead, read := m.s.hashes[userMeta]["last_read"]
+	count := m.countUnread(user, lastRead, read)
+	m.s.hset(userMeta, "unread_count", count)
+
+	return count, nil
+}
+
+// LastRead is LastRead when feed was last paginated
+func (m MemoryBackend) LastRead(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, m.fail("last read", user, err)
+	}
+
+	userMeta := m.metaKey(user)
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	timestamp, ok := m.s.hashes[userMeta]["last_read"]
+	if !ok {
+		return 0, m.fail("last read", user, ErrUserNotFound)
+	}
+
+	return timestamp, nil
+}
+
+// ResetLastRead resets last read time stamp
+func (m MemoryBackend) ResetLastRead(ctx context.Context, user string, at int64) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, m.fail("reset last read", user, err)
+	}
+
+	userMeta := m.metaKey(user)
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	created := m.s.hset(userMeta, "last_read", at)
+	delete(m.s.values, m.flagsKey(user))
+	m.s.hset(userMeta, "unread_count", m.countUnread(user, at, true))
+
+	return created, nil
+}
+
+// MarkRead flags events read and returns the number of events that were unread
+func (m MemoryBackend) MarkRead(ctx context.Context, user string, ids ...string) (int64, error) {
+	return m.mark(ctx, "mark read", user, "1", ids)
+}
+
+// MarkUnread flags events unread and returns the number of events that were read
+func (m MemoryBackend) MarkUnread(ctx context.Context, user string, ids ...string) (int64, error) {
+	return m.mark(ctx, "mark unread", user, "0", ids)
+}
+
+// mark flags events read with "1" or unread with "0" and moves the unread count by the change,
+// only events whose flag differs from the watermark are flagged
+func (m MemoryBackend) mark(ctx context.Context, op, user, flag string, ids []string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, m.fail(op, user, err)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	userMeta := m.metaKey(user)
+	userFlags := m.flagsKey(user)
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	lastRead, read := m.s.hashes[userMeta]["last_read"]
+
+	var want int64
+	if flag == "0" {
+		want = 1
+	}
+
+	var change, changed int64
+	for _, id := range ids {
+		score, ok := m.s.zscore(m.dataKey(user), id)
+		if !ok {
+			continue
+		}
+
+		before := m.unread(user, id, score)
+
+		if want == watermark(m.at(user, id, score), lastRead, read) {
+			m.s.hdelValues(userFlags, id)
+		} else {
+			m.s.hsetValue(userFlags, id, flag)
+		}
+
+		if before != want {
+			change += want - before
+			changed++
+		}
+	}
+
+	m.s.hincrBy(userMeta, "unread_count", change)
+
+	return changed, nil
+}
+
+// MarkAllReadUpTo marks an event and every event before it read, reporting whether the event existed.
+// The watermark moves past the event, the events sharing its time but sorting after it keep their state
+func (m MemoryBackend) MarkAllReadUpTo(ctx context.Context, user, id string) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, m.fail("mark read", user, err)
+	}
+
+	userData := m.dataKey(user)
+	userMeta := m.metaKey(user)
+	userFlags := m.flagsKey(user)
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	score, ok := m.s.zscore(userData, id)
+	if !ok {
+		return false, nil
+	}
+
+	at := m.at(user, id, score)
+
+	keep := map[string]int64{}
+	for _, e := range m.s.sets[userData] {
+		if e.member > id && m.at(user, e.member, e.score) == at {
+			keep[e.member] = m.unread(user, e.member, e.score)
+		}
+	}
+
+	lastRead, read := m.s.hashes[userMeta]["last_read"]
+	if !read || float64(lastRead) < at+1 {
+		lastRead = int64(at) + 1
+		m.s.hset(userMeta, "last_read", lastRead)
+	}
+
+	for flagged := range m.s.values[userFlags] {
+		if score, ok := m.s.zscore(userData, flagged); !ok || m.at(user, flagged, score) <= at {
+			m.s.hdelValues(userFlags, flagged)
+		}
+	}
+
+	for member, state := range keep {
+		if state == 1 {
+			m.s.hsetValue(userFlags, member, "0")
+		}
+	}
+
+	m.s.hset(userMeta, "unread_count", m.countUnread(user, lastRead, true))
+
+	return true, nil
+}
+
+// UnRead returns the total count of un read feed items against the last read at, events
+// flagged read or unread count by their flag
+func (m MemoryBackend) UnRead(ctx context.Context, user string, at int64) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, m.fail("unread", user, err)
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	return m.countUnread(user, at, true), nil
+}
+
+// unread returns 1 when the event id of the user is unread, by its flag or else by the
+// watermark. The store must be locked
+func (m MemoryBackend) unread(user, id string, score float64) int64 {
+	if flag, ok := m.s.values[m.flagsKey(user)][id]; ok {
+		if flag == "0" {
+			return 1
+		}
+		return 0
+	}
+
+	lastRead, read := m.s.hashes[m.metaKey(user)]["last_read"]
+
+	return watermark(m.at(user, id, score), lastRead, read)
+}
+
+// at returns the time of the event id of the user scored score, the store must be locked
+func (m MemoryBackend) at(user, id string, score float64) float64 {
+	if t, ok := m.s.values[m.timesKey(user)][id]; ok {
+		// the times are written by the backend itself so they always parse
+		at, _ := strconv.ParseFloat(t, 64)
+		return at
+	}
+	return score
+}
+
+// countUnread counts the unread events of the user against lastRead, read is false when the
+// user never read the feed. The store must be locked
+func (m MemoryBackend) countUnread(user string, lastRead int64, read bool) int64 {
+	flags := m.s.values[m.flagsKey(user)]
+
+	var count int64
+	for _, e := range m.s.sets[m.dataKey(user)] {
+		if flag, ok := flags[e.member]; ok {
+			if flag == "0" {
+				count++
+			}
+			continue
+		}
+		count += watermark(m.at(user, e.member, e.score), lastRead, read)
+	}
+
+	return count
+}
+
+// RecalculateCount count recalculates the length of events, it returns 0 like Redis
+func (m MemoryBackend) RecalculateCount(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, m.fail("recalculate count", user, err)
+	}
+
+	userMeta := m.metaKey(user)
+	userData := m.dataKey(user)
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	count := int64(len(m.s.sets[userData]))
+
+	m.s.hset(userMeta, "total_count", count)
+
+	return 0, nil
+}
+
+// zadd adds or rescores a member, returning 1 when the member is new
+func (s *memoryStore) zadd(key string, m memoryMember) int64 {
+	set := s.sets[key]
+
+	var added int64 = 1
+	for i, e := range set {
+		if e.member == m.member {
+			set = append(set[:i:i], set[i+1:]...)
+			added = 0
+			break
+		}
+	}
+
+	i := sort.Search(len(set), func(i int) bool { return m.less(set[i]) })
+	set = append(set, memoryMember{})
+	copy(set[i+1:], set[i:])
+	set[i] = m
+
+	s.setMembers(key, set)
+
+	return added
+}
+
+// zscore returns the score of a member
+func (s *memoryStore) zscore(key, member string) (float64, bool) {
+	for _, e := range s.sets[key] {
+		if e.member == member {
+			return e.score, true
+		}
+	}
+	return 0, false
+}
+
+// zrem removes a member, returning its score
+func (s *memoryStore) zrem(key, member string) (float64, bool) {
+	set := s.sets[key]
+
+	for i, e := range set {
+		if e.member == member {
+			s.setMembers(key, append(set[:i:i], set[i+1:]...))
+			return e.score, true
+		}
+	}
+
+	return 0, false
+}
+
+// zrevrange returns the members between start and stop in descending order
+func (s *memoryStore) zrevrange(key string, start, stop int) []memoryMember {
+	set := s.sets[key]
+	results := []memoryMember{}
+
+	from, to, ok := rankRange(len(set), start, stop)
+	if !ok {
+		return results
+	}
+
+	for i := from; i <= to; i++ {
+		results = append(results, set[len(set)-1-i])
+	}
+
+	return results
+}
+
+// setMembers replaces a sorted set, dropping the key once it is empty like Redis does
+func (s *memoryStore) setMembers(key string, set []memoryMember) {
+	if len(set) == 0 {
+		delete(s.sets, key)
+		return
+	}
+	s.sets[key] = set
+}
+
+// hset sets a hash field, returning true when the field is new
+func (s *memoryStore) hset(key, field string, value int64) bool {
+	hash, ok := s.hashes[key]
+	if !ok {
+		hash = map[string]int64{}
+		s.hashes[key] = hash
+	}
+
+	_, exists := hash[field]
+	hash[field] = value
+
+	return !exists
+}
+
+// hincrBy increments a hash field by change
+func (s *memoryStore) hincrBy(key, field string, change int64) int64 {
+	hash, ok := s.hashes[key]
+	if !ok {
+		hash = map[string]int64{}
+		s.hashes[key] = hash
+	}
+
+	hash[field] += change
+
+	return hash[field]
+}
+
+// hsetValue sets a field of a payload hash
+func (s *memoryStore) hsetValue(key, field, value string) {
+	hash, ok := s.values[key]
+	if !ok {
+		hash = map[string]string{}
+		s.values[key] = hash
+	}
+
+	hash[field] = value
+}
+
+// hdelValues removes fields of a payload hash, dropping the key once it is empty like Redis does
+func (s *memoryStore) hdelValues(key string, fields ...string) {
+	hash, ok := s.values[key]
+	if !ok {
+		return
+	}
+
+	for _, field := range fields {
+		delete(hash, field)
+	}
+
+	if len(hash) == 0 {
+		delete(s.values, key)
+	}
+}
+
+// watermark returns 1 when an event that happened at happened at or after lastRead, every event
+// did when the user never read the feed
+func watermark(at float64, lastRead int64, read bool) int64 {
+	if !read || at >= float64(lastRead) {
+		return 1
+	}
+	return 0
+}
+
+// rankRange normalises Redis style start and stop ranks (negative values count from the end)
+// against a set of length n
+func rankRange(n, start, stop int) (int, int, bool) {
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop || start >= n {
+		return 0, 0, false
+	}
+
+	return start, stop, true
+}
+
+// NewMemoryBackend returns an instance of the MemoryBackend struct
+func NewMemoryBackend() Backend {
+	backend := MemoryBackend{
+		s: &memoryStore{
+			sets:   map[string][]memoryMember{},
+			hashes: map[string]map[string]int64{},
+			values: map[string]map[string]string{},
+		},
+	}
+
+	return backend
+}
@@ -0,0 +1,182 @@
+package feeder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryGraph is a Graph kept in memory, it is safe for concurrent use and its zero value is
+// an empty graph
+type MemoryGraph struct {
+	mu sync.RWMutex
+	// followers and following hold when each follow was made, by user and the user followed
+	// or following
+	followers map[string]map[string]int64
+	following map[string]map[string]int64
+}
+
+// Follow makes follower follow followee and reports whether it did not already
+func (m *MemoryGraph) Follow(ctx context.Context, follower, followee string) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, opError("follow", "", follower, err)
+	}
+
+	if err := checkFollow(follower, followee); err != nil {
+		return false, opError("follow", "", follower, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.following[follower][followee]; ok {
+		return false, nil
+	}
+
+	at := time.Now().Unix()
+
+	if m.following == nil {
+		m.following = map[string]map[string]int64{}
+		m.followers = map[string]map[string]int64{}
+	}
+
+	if m.following[follower] == nil {
+		m.following[follower] = map[string]int64{}
+	}
+	if m.followers[followee] == nil {
+		m.followers[followee] = map[string]int64{}
+	}
+
+	m.following[follower][followee] = at
+	m.followers[followee][follower] = at
+
+	return true, nil
+}
+
+// Unfollow stops follower following followee and reports whether it did
+func (m *MemoryGraph) Unfollow(ctx context.Context, follower, followee string) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, opError("unfollow", "", follower, err)
+	}
+
+	if err := checkFollow(follower, followee); err != nil {
+		return false, opError("unfollow", "", follower, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.following[follower][followee]; !ok {
+		return false, nil
+	}
+
+	delete(m.following[follower], followee)
+	delete(m.followers[followee], follower)
+
+	if len(m.following[follower]) == 0 {
+		delete(m.following, follower)
+	}
+	if len(m.followers[followee]) == 0 {
+		delete(m.followers, followee)
+	}
+
+	return true, nil
+}
+
+// Followers paginates the users following user, the latest first
+func (m *MemoryGraph) Followers(ctx context.Context, user string, page, perPage int) ([]string, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, opError("followers", "", user, err)
+	}
+
+	if page < 1 || perPage < 1 {
+		return nil, opError("followers", "", user, fmt.Errorf("%w: page %d of %d", ErrInvalidPage, page, perPage))
+	}
+
+	m.mu.RLock()
+	followers := latestFollows(m.followers[user])
+	m.mu.RUnlock()
+
+	from := (page - 1) * perPage
+	if from > len(followers) {
+		from = len(followers)
+	}
+
+	to := from + perPage
+	if to > len(followers) {
+		to = len(followers)
+	}
+
+	return followers[from:to], nil
+}
+
+// FollowersAfter returns up to limit follows of the users following user made before after,
+// the latest first
+func (m *MemoryGraph) FollowersAfter(ctx context.Context, user string, after Follow, limit int) ([]Follow, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, opError("followers after", "", user, err)
+	}
+
+	if limit < 1 {
+		return nil, opError("followers after", "", user, fmt.Errorf("%w: limit %d", ErrInvalidPage, limit))
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	follows := []Follow{}
+
+	for _, follower := range latestFollows(m.followers[user]) {
+		at := m.followers[user][follower]
+
+		if !after.IsZero() && !after.before(follower, at) {
+			continue
+		}
+
+		if follows = append(follows, Follow{User: follower, At: at}); len(follows) == limit {
+			break
+		}
+	}
+
+	return follows, nil
+}
+
+// Following returns every user that user follows, the latest first
+func (m *MemoryGraph) Following(ctx context.Context, user string) ([]string, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, opError("following", "", user, err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return latestFollows(m.following[user]), nil
+}
+
+// latestFollows lists the users of follows the latest first, follows of the same second are
+// ordered by user ID descending like a Redis sorted set read in reverse
+func latestFollows(follows map[string]int64) []string {
+	users := make([]string, 0, len(follows))
+	for user := range follows {
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if follows[users[i]] != follows[users[j]] {
+			return follows[users[i]] > follows[users[j]]
+		}
+		return users[i] > users[j]
+	})
+
+	return users
+}
+
+// NewMemoryGraph returns an empty graph kept in memory
+func NewMemoryGraph() Graph {
+	return &MemoryGraph{
+		followers: map[string]map[string]int64{},
+		following: map[string]map[string]int64{},
+	}
+}
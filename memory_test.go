@@ -0,0 +1,389 @@
+package feeder
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewMemoryBackend(t *testing.T) {
+
+	ctx := context.Background()
+
+	client := NewMemoryBackend()
+
+	want := "PONG"
+	got, err := client.HealthCheck(ctx)
+
+	if err != nil {
+		t.Errorf("health check error %s", err)
+	}
+
+	if got != want {
+		t.Errorf("we did not get a valid response from the backend got %s want %s", got, want)
+	}
+
+}
+
+func TestMemoryStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	client := NewMemoryBackend()
+
+	user := "okandas"
+	at := time.Now().Unix()
+
+	for i := 0; i < DefaultSize; i++ {
+		got, err := client.Store(ctx, user, "event "+strconv.Itoa(i), at+int64(i))
+
+		if err != nil {
+			t.Errorf("store action error %s", err)
+			return
+		}
+
+		if got != 1 {
+			t.Errorf("store failed to store an event for user got %d want %d", got, 1)
+			return
+		}
+	}
+
+	// the feed is full so the oldest event is evicted
+	got, err := client.Store(ctx, user, "newest", at+DefaultSize)
+
+	if err != nil {
+		t.Errorf("store action error %s", err)
+		return
+	}
+
+	if got != 0 {
+		t.Errorf("store on a full feed got %d want %d", got, 0)
+	}
+
+	all, _ := client.All(ctx, user)
+
+	if len(all) != DefaultSize {
+		t.Errorf("got %d events wanted %d", len(all), DefaultSize)
+		return
+	}
+
+	if all[0].Value != "newest" || all[len(all)-1].Value != "event 1" {
+		t.Errorf("trimmed the wrong end of the feed got %v", all)
+	}
+
+}
+
+func TestMemoryDelete(t *testing.T) {
+
+	ctx := context.Background()
+
+	client := NewMemoryBackend()
+
+	// test setup
+	user := "okandas"
+	value := "storing event for user"
+	at := time.Now().Unix()
+	client.Store(ctx, user, value, at)
+
+	want := int64(1)
+	got, err := client.Delete(ctx, user, value, at)
+
+	if err != nil {
+		t.Errorf("error %s", err)
+		return
+	}
+
+	if got != want {
+		t.Errorf("got %d wanted %d", got, want)
+	}
+
+	got, _ = client.Delete(ctx, user, value, at)
+
+	if got != 0 {
+		t.Errorf("deleting a missing event got %d wanted %d", got, 0)
+	}
+
+}
+
+func TestMemoryWipe(t *testing.T) {
+
+	ctx := context.Background()
+
+	client := NewMemoryBackend()
+
+	// test setup
+	user := "okandas"
+	client.Store(ctx, user, "storing event for user", time.Now().Unix())
+
+	var want int64 = 1
+
+	got, err := client.Wipe(ctx, user)
+
+	if err != nil {
+		t.Errorf("error %s", err)
+		return
+	}
+
+	if got != want {
+		t.Errorf("got %d wanted %d", got, want)
+	}
+
+	all, _ := client.All(ctx, user)
+
+	if len(all) != 0 {
+		t.Errorf("wipe left %d events behind", len(all))
+	}
+
+}
+
+func TestMemoryPaginate(t *testing.T) {
+
+	ctx := context.Background()
+
+	client := NewMemoryBackend()
+
+	// test setup
+	user := "okandas"
+	values := []string{"first", "second", "third", "fourth", "fifth", "sixth"}
+
+	for i, value := range values {
+		client.Store(ctx, user, value, int64(i))
+	}
+
+	tt := []struct {
+		page    int
+		perPage int
+		want    []string
+	}{
+		{page: 1, perPage: 4, want: []string{"sixth", "fifth", "fourth", "third"}},
+		{page: 2, perPage: 4, want: []string{"second", "first"}},
+		{page: 3, perPage: 4, want: []string{}},
+	}
+
+	for _, tc := range tt {
+		got, err := client.Paginate(ctx, user, tc.page, tc.perPage)
+
+		if err != nil {
+			t.Errorf("error %s", err)
+			return
+		}
+
+		if !cmp.Equal(itemValues(got), tc.want) {
+			t.Errorf("page %d got %v wanted %v", tc.page, itemValues(got), tc.want)
+		}
+	}
+
+}
+
+func TestMemoryCount(t *testing.T) {
+
+	ctx := context.Background()
+
+	client := NewMemoryBackend()
+
+	user := "okandas"
+
+	if _, err := client.Count(ctx, user); err == nil {
+		t.Errorf("expected an error counting a user without events")
+	}
+
+	values := []string{"first", "second", "third"}
+
+	for _, value := range values {
+		client.Store(ctx, user, value, time.Now().Unix())
+	}
+
+	want := 3
+	got, err := client.Count(ctx, user)
+
+	if err != nil {
+		t.Errorf("error %s", err)
+		return
+	}
+
+	if got != want {
+		t.Errorf("got %d wanted %d", got, want)
+	}
+
+}
+
+func TestMemoryUnReadCount(t *testing.T) {
+
+	ctx := context.Background()
+
+	client := NewMemoryBackend()
+
+	// test setup
+	user := "okandas"
+	now := time.Now()
+
+	client.ResetLastRead(ctx, user, now.Unix())
+
+	client.Store(ctx, user, "first", now.Add(-time.Minute).Unix())
+	client.Store(ctx, user, "second", now.Unix())
+	client.Store(ctx, user, "third", now.Add(time.Minute).Unix())
+
+	lastRead, err := client.LastRead(ctx, user)
+
+	if err != nil {
+		t.Errorf("error %s", err)
+		return
+	}
+
+	var want int64 = 2
+	got, err := client.UnRead(ctx, user, lastRead)
+
+	if err != nil {
+		t.Errorf("error %s", err)
+		return
+	}
+
+	if got != want {
+		t.Errorf("got %d wanted %d", got, want)
+	}
+
+}
+
+func TestMemoryRecalculateCount(t *testing.T) {
+
+	ctx := context.Background()
+
+	client := NewMemoryBackend()
+
+	// test setup
+	user := "okandas"
+	client.Store(ctx, user, "first", time.Now().Unix())
+	client.Store(ctx, user, "second", time.Now().Unix())
+	client.Delete(ctx, user, "second", 0)
+
+	if _, err := client.RecalculateCount(ctx, user); err != nil {
+		t.Errorf("error %s", err)
+		return
+	}
+
+	got, _ := client.Count(ctx, user)
+
+	if got != 1 {
+		t.Errorf("recalculate count got %d wanted %d", got, 1)
+	}
+
+}
+
+func TestMemoryConcurrentStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	client := NewMemoryBackend()
+
+	user := "okandas"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client.Store(ctx, user, "event "+strconv.Itoa(i), int64(i))
+		}(i)
+	}
+	wg.Wait()
+
+	got, _ := client.Count(ctx, user)
+
+	if got != 10 {
+		t.Errorf("concurrent stores got %d wanted %d", got, 10)
+	}
+
+}
+
+func TestMemoryMatchesRedis(t *testing.T) {
+
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	// both backends implement every optional interface
+	type fullBackend interface {
+		Backend
+		EditBackend
+		MarkBackend
+		RescoreBackend
+		PinBackend
+	}
+
+	backends := []fullBackend{NewRedisClient(c).(Redis), NewMemoryBackend().(MemoryBackend)}
+
+	user := "okandas"
+
+	for _, b := range backends {
+		b.ResetLastRead(ctx, user, 10)
+		for i := 0; i < 20; i++ {
+			// equal scores make the tie break on the member visible
+			b.Store(ctx, user, "event "+strconv.Itoa(i), int64(i/2))
+		}
+		b.Store(ctx, user, "event 19", 3)
+		b.Delete(ctx, user, "event 15", 0)
+		b.DeleteID(ctx, user, "event 16")
+		b.Update(ctx, user, "event 14", "edited")
+		b.Move(ctx, user, "event 13", 4)
+		b.Move(ctx, user, "event 2", 11)
+		b.MarkRead(ctx, user, "event 18", "event 3")
+		b.MarkUnread(ctx, user, "event 4", "event 17")
+		b.MarkAllReadUpTo(ctx, user, "event 10")
+		// ranked events keep their time apart from their score
+		b.StoreID(ctx, user, "ranked", "ranked", 12, 2.5)
+		b.Rescore(ctx, user, map[string]float64{"event 9": 0.5, "event 12": 6})
+		b.Move(ctx, user, "event 9", 9)
+		b.Pin(ctx, user, "event 0", 0)
+		b.SetPriority(ctx, user, "event 1", 2)
+		b.Store(ctx, user, "event 20", 20)
+	}
+
+	redisAll, _ := backends[0].All(ctx, user)
+	memoryAll, _ := backends[1].All(ctx, user)
+
+	if !cmp.Equal(redisAll, memoryAll) {
+		t.Errorf("all differs redis %v memory %v", redisAll, memoryAll)
+	}
+
+	redisPage, _ := backends[0].Paginate(ctx, user, 2, 3)
+	memoryPage, _ := backends[1].Paginate(ctx, user, 2, 3)
+
+	if !cmp.Equal(redisPage, memoryPage) {
+		t.Errorf("paginate differs redis %v memory %v", redisPage, memoryPage)
+	}
+
+	redisCount, _ := backends[0].Count(ctx, user)
+	memoryCount, _ := backends[1].Count(ctx, user)
+
+	if redisCount != memoryCount {
+		t.Errorf("count differs redis %d memory %d", redisCount, memoryCount)
+	}
+
+	redisUnread, _ := backends[0].UnRead(ctx, user, 5)
+	memoryUnread, _ := backends[1].UnRead(ctx, user, 5)
+
+	if redisUnread != memoryUnread {
+		t.Errorf("unread differs redis %d memory %d", redisUnread, memoryUnread)
+	}
+
+	memoryMeta := backends[1].(MemoryBackend).s.hashes["okandas.meta"]
+
+	if got := server.HGet("okandas.meta", "unread_count"); got != strconv.FormatInt(memoryMeta["unread_count"], 10) {
+		t.Errorf("unread count differs redis %s memory %d", got, memoryMeta["unread_count"])
+	}
+
+}
@@ -0,0 +1,405 @@
+package feeder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// msgpackCodec encodes an action as a MessagePack map keyed by the JSON names of its fields,
+// the time is a MessagePack timestamp and empty actors and objects are left out. It only reads the types an action is made of and skips
+// the keys it does not know, so fields can be added later
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string  { return "msgpack" }
+func (msgpackCodec) Version() byte { return MessagePackVersion }
+
+func (msgpackCodec) Encode(action Action) ([]byte, error) {
+	var e msgpackEncoder
+
+	n := 4
+	if action.Actor != "" {
+		n++
+	}
+	if action.Object != "" {
+		n++
+	}
+
+	e.mapHeader(n)
+	e.str("value")
+	e.str(action.Value)
+	e.str("at")
+	e.timestamp(action.At)
+	e.str("method")
+	e.str(action.Method)
+
+	if action.Actor != "" {
+		e.str("actor")
+		e.str(action.Actor)
+	}
+	if action.Object != "" {
+		e.str("object")
+		e.str(action.Object)
+	}
+
+	e.str("meta")
+
+	if action.Meta == nil {
+		e.nil()
+	} else {
+		keys := make([]string, 0, len(action.Meta))
+		for k := range action.Meta {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		e.mapHeader(len(keys))
+		for _, k := range keys {
+			e.str(k)
+			e.str(action.Meta[k])
+		}
+	}
+
+	return e.b, nil
+}
+
+func (msgpackCodec) Decode(data []byte) (Action, error) {
+	var action Action
+
+	d := msgpackDecoder{b: data}
+
+	n, err := d.mapHeader()
+	if err != nil {
+		return action, err
+	}
+
+	for i := 0; i < n; i++ {
+		key, err := d.str()
+		if err != nil {
+			return action, err
+		}
+
+		switch key {
+		case "value":
+			action.Value, err = d.str()
+		case "at":
+			action.At, err = d.timestamp()
+		case "method":
+			action.Method, err = d.str()
+		case "actor":
+			action.Actor, err = d.str()
+		case "object":
+			action.Object, err = d.str()
+		case "meta":
+			action.Meta, err = d.strMap()
+		default:
+			err = d.skip()
+		}
+
+		if err != nil {
+			return action, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	if len(d.b) > 0 {
+		return action, errors.New("trailing data")
+	}
+
+	return action, nil
+}
+
+// msgpackEncoder appends MessagePack values to b
+type msgpackEncoder struct {
+	b []byte
+}
+
+func (e *msgpackEncoder) nil() {
+	e.b = append(e.b, 0xc0)
+}
+
+func (e *msgpackEncoder) mapHeader(n int) {
+	switch {
+	case n < 16:
+		e.b = append(e.b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, 0xde), uint16(n))
+	default:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xdf), uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) str(s string) {
+	switch n := len(s); {
+	case n < 32:
+		e.b = append(e.b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.b = append(e.b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, 0xda), uint16(n))
+	default:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xdb), uint32(n))
+	}
+	e.b = append(e.b, s...)
+}
+
+// timestamp appends t as the 96 bit timestamp extension, which holds any time
+func (e *msgpackEncoder) timestamp(t time.Time) {
+	e.b = append(e.b, 0xc7, 12, 0xff)
+	e.b = binary.BigEndian.AppendUint32(e.b, uint32(t.Nanosecond()))
+	e.b = binary.BigEndian.AppendUint64(e.b, uint64(t.Unix()))
+}
+
+// msgpackDecoder reads MessagePack values from the front of b
+type msgpackDecoder struct {
+	b []byte
+}
+
+var errShortData = errors.New("unexpected end of data")
+
+// next consumes n bytes
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.b) < n {
+		return nil, errShortData
+	}
+	p := d.b[:n]
+	d.b = d.b[n:]
+	return p, nil
+}
+
+// uint reads a big endian unsigned integer of size bytes
+func (d *msgpackDecoder) uint(size int) (int, error) {
+	p, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+
+	var n uint64
+	for _, c := range p {
+		n = n<<8 | uint64(c)
+	}
+
+	if n > math.MaxInt32 {
+		return 0, fmt.Errorf("length %d too large", n)
+	}
+
+	return int(n), nil
+}
+
+func (d *msgpackDecoder) code() (byte, error) {
+	p, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return p[0], nil
+}
+
+func (d *msgpackDecoder) mapHeader() (int, error) {
+	c, err := d.code()
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case c&0xf0 == 0x80:
+		return int(c & 0x0f), nil
+	case c == 0xde:
+		return d.uint(2)
+	case c == 0xdf:
+		return d.uint(4)
+	}
+
+	return 0, fmt.Errorf("want a map, got %#x", c)
+}
+
+func (d *msgpackDecoder) str() (string, error) {
+	c, err := d.code()
+	if err != nil {
+		return "", err
+	}
+
+	var n int
+
+	switch {
+	case c&0xe0 == 0xa0:
+		n = int(c & 0x1f)
+	case c == 0xd9:
+		n, err = d.uint(1)
+	case c == 0xda:
+		n, err = d.uint(2)
+	case c == 0xdb:
+		n, err = d.uint(4)
+	default:
+		return "", fmt.Errorf("want a string, got %#x", c)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	p, err := d.next(n)
+	return string(p), err
+}
+
+// strMap reads a map of strings, nil reads as a nil map
+func (d *msgpackDecoder) strMap() (map[string]string, error) {
+	if len(d.b) > 0 && d.b[0] == 0xc0 {
+		d.b = d.b[1:]
+		return nil, nil
+	}
+
+	n, err := d.mapHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	// the length is read from the payload, every entry takes at least two bytes of it
+	hint := n
+	if hint > len(d.b)/2 {
+		hint = len(d.b) / 2
+	}
+
+	m := make(map[string]string, hint)
+
+	for i := 0; i < n; i++ {
+		k, err := d.str()
+		if err != nil {
+			return nil, err
+		}
+		if m[k], err = d.str(); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// timestamp reads any of the 32, 64 and 96 bit timestamp extensions
+func (d *msgpackDecoder) timestamp() (time.Time, error) {
+	c, err := d.code()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var n int
+
+	switch c {
+	case 0xd6:
+		n = 4
+	case 0xd7:
+		n = 8
+	case 0xc7:
+		if n, err = d.uint(1); err != nil {
+			return time.Time{}, err
+		}
+	default:
+		return time.Time{}, fmt.Errorf("want a timestamp, got %#x", c)
+	}
+
+	p, err := d.next(n + 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if int8(p[0]) != -1 {
+		return time.Time{}, fmt.Errorf("want a timestamp, got extension %d", int8(p[0]))
+	}
+
+	p = p[1:]
+
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(p)), 0), nil
+	case 8:
+		v := binary.BigEndian.Uint64(p)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)), nil
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(p[4:])), int64(binary.BigEndian.Uint32(p))), nil
+	}
+
+	return time.Time{}, fmt.Errorf("timestamp of %d bytes", n)
+}
+
+// skip consumes a value of any type
+func (d *msgpackDecoder) skip() error {
+	c, err := d.code()
+	if err != nil {
+		return err
+	}
+
+	var n int
+
+	switch {
+	case c <= 0x7f, c >= 0xe0, c == 0xc0, c == 0xc2, c == 0xc3:
+		return nil
+	case c&0xf0 == 0x80:
+		return d.skipN(2 * int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return d.skipN(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		_, err = d.next(int(c & 0x1f))
+		return err
+	case c == 0xcc, c == 0xd0:
+		_, err = d.next(1)
+		return err
+	case c == 0xcd, c == 0xd1:
+		_, err = d.next(2)
+		return err
+	case c == 0xca, c == 0xce, c == 0xd2:
+		_, err = d.next(4)
+		return err
+	case c == 0xcb, c == 0xcf, c == 0xd3:
+		_, err = d.next(8)
+		return err
+	case c >= 0xd4 && c <= 0xd8:
+		_, err = d.next(1 + 1<<(c-0xd4))
+		return err
+	case c == 0xc4, c == 0xd9:
+		n, err = d.uint(1)
+	case c == 0xc5, c == 0xda:
+		n, err = d.uint(2)
+	case c == 0xc6, c == 0xdb:
+		n, err = d.uint(4)
+	case c == 0xc7, c == 0xc8, c == 0xc9:
+		if n, err = d.uint(1 << (c - 0xc7)); err == nil {
+			n++
+		}
+	case c == 0xdc:
+		if n, err = d.uint(2); err == nil {
+			return d.skipN(n)
+		}
+	case c == 0xdd:
+		if n, err = d.uint(4); err == nil {
+			return d.skipN(n)
+		}
+	case c == 0xde:
+		if n, err = d.uint(2); err == nil {
+			return d.skipN(2 * n)
+		}
+	case c == 0xdf:
+		if n, err = d.uint(4); err == nil {
+			return d.skipN(2 * n)
+		}
+	default:
+		return fmt.Errorf("unknown type %#x", c)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = d.next(n)
+	return err
+}
+
+// skipN consumes n values
+func (d *msgpackDecoder) skipN(n int) error {
+	for i := 0; i < n; i++ {
+		if err := d.skip(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
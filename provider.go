@@ -1,7 +1,102 @@
 package feeder
 
-// Backend is the backend of our feeds
+import (
+	"context"
+	"strings"
+)
+
+// DefaultSize is the number of events kept per user when a feed does not set its size
+const DefaultSize = 17
+
+// Backend is the backend of our feeds, every call honours the deadline and cancellation of its context
 type Backend interface {
+	// Store stores an event with its value as its ID, so storing a value again moves the event.
+	// StoreID stores an event under an ID, the value is returned as the Value of its Item. The
+	// feed is ordered and trimmed by score, the time at is kept apart when the two differ
+	Store(ctx context.Context, user, value string, at int64) (int64, error)
+	StoreID(ctx context.Context, user, id, value string, at int64, score float64) (int64, error)
+	// Delete removes the event stored with value as its ID. DeleteID removes a single event,
+	// keeps the counters right and reports whether the event existed
+	Delete(ctx context.Context, user, value string, at int64) (int64, error)
+	DeleteID(ctx context.Context, user, id string) (bool, error)
+	HealthCheck(ctx context.Context) (string, error)
+	Wipe(ctx context.Context, user string) (int64, error)
+	// All and Paginate return the pinned events and then the others, each highest score first,
+	// marked unread when they happened at or after the users last read time stamp
+	All(ctx context.Context, user string) ([]Item, error)
+	Paginate(ctx context.Context, user string, page, perPage int) ([]Item, error)
+	// After returns up to limit events after the cursor and Before up to limit events before
+	// it, both in the order of the feed. The zero cursor is the position before the first event
+	After(ctx context.Context, user string, cursor Cursor, limit int) ([]Item, error)
+	Before(ctx context.Context, user string, cursor Cursor, limit int) ([]Item, error)
+	// Between returns the events that happened at or after from and before to in the order of the feed
+	Between(ctx context.Context, user string, from, to int64) ([]Item, error)
+	Count(ctx context.Context, user string) (int, error)
+	// UnRead counts the events unread against the last read at, events marked read or
+	// unread count by their mark
+	UnRead(ctx context.Context, user string, at int64) (int64, error)
+	// UnreadCount returns the unread count kept along with the events in a single read,
+	// RecalculateUnread recounts it from the events when it drifted
+	UnreadCount(ctx context.Context, user string) (int64, error)
+	RecalculateUnread(ctx context.Context, user string) (int64, error)
+	// ResetLastRead moves the last read time stamp and drops the marks of every event
+	ResetLastRead(ctx context.Context, user string, at int64) (bool, error)
+	LastRead(ctx context.Context, user string) (int64, error)
+	RecalculateCount(ctx context.Context, user string) (int64, error)
+	// WithFeed returns a copy of the backend whose keys are namespaced by the feed name
+	// and whose events are trimmed to size
+	WithFeed(name string, size int) Backend
+}
+
+// EditBackend is implemented by backends that can change single events in place. Activity
+// returns ErrNotSupported for Update and Move on other backends
+type EditBackend interface {
+	// Update and Move change a single event in place, keep the counters right and report
+	// whether the event existed
+	Update(ctx context.Context, user, id, value string) (bool, error)
+	Move(ctx context.Context, user, id string, at int64) (bool, error)
+}
+
+// MarkBackend is implemented by backends that keep the read state of single events next to
+// the last read time stamp. Activity returns ErrNotSupported for marks on other backends
+type MarkBackend interface {
+	// MarkRead and MarkUnread mark single events and return the number of events whose state
+	// changed. MarkAllReadUpTo marks an event and every event before it read
+	MarkRead(ctx context.Context, user string, ids ...string) (int64, error)
+	MarkUnread(ctx context.Context, user string, ids ...string) (int64, error)
+	MarkAllReadUpTo(ctx context.Context, user, id string) (bool, error)
+}
+
+// GroupBackend is implemented by backends that can aggregate actions into groups as they are
+// stored. Activity returns ErrNotSupported for grouped actions on other backends
+type GroupBackend interface {
+	// StoreGroup adds an event to the group open for key when the group started at most window
+	// seconds before at, or else stores it under id as a new group of the actor
+	StoreGroup(ctx context.Context, user, id, value string, at int64, score float64, key, actor string, window int64) (int64, error)
+}
+
+// RescoreBackend is implemented by backends that can score events apart from their time.
+// Activity returns ErrNotSupported for Rescore on other backends
+type RescoreBackend interface {
+	// Rescore scores events anew by their ID, keeping the time they happened at, and returns
+	// the number of events rescored. Events moved with Move keep their score when it is not their time
+	Rescore(ctx context.Context, user string, scores map[string]float64) (int64, error)
+}
+
+// PinBackend is implemented by backends that can pin events and trim them by priority.
+// Activity returns ErrNotSupported for pins and priorities on other backends
+type PinBackend interface {
+	// Pin pins an event until the time stamp until, 0 for until Unpin, and SetPriority sets the
+	// priority of an event. Pinned events come first in All and Paginate and are not trimmed,
+	// the others are trimmed lowest priority first. Each reports whether the event existed
+	Pin(ctx context.Context, user, id string, until int64) (bool, error)
+	Unpin(ctx context.Context, user, id string) (bool, error)
+	SetPriority(ctx context.Context, user, id string, priority int) (bool, error)
+}
+
+// LegacyBackend is the Backend interface before calls took a context,
+// wrap implementations of it with FromLegacy
+type LegacyBackend interface {
 	Store(user, value string, at int64) (int64, error)
 	Delete(user, value string, at int64) (int64, error)
 	HealthCheck() (string, error)
@@ -14,3 +109,25 @@ type Backend interface {
 	LastRead(user string) (int64, error)
 	RecalculateCount(user string) (int64, error)
 }
+
+// key namespaces a user by the global prefix and the feed name, empty parts are left out
+//
+//	{prefix}:{feed}:{user}
+func key(prefix, feed, user string) string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{prefix, feed} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(append(parts, user), ":")
+}
+
+// feedSize is the number of events a feed keeps per user
+func feedSize(size int) int {
+	if size <= 0 {
+		return DefaultSize
+	}
+	return size
+}
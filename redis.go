@@ -1,184 +1,699 @@
 package feeder
 
 import (
-	"log"
-	"math"
+	"context"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/go-redis/redis"
 )
 
-// Redis is a wrapper that implements the Backend Interface and represents a connection to a Redis server
+func init() {
+	RegisterDriver("redis", DriverFunc(openRedis))
+}
+
+// Redis is a wrapper that implements the Backend Interface and represents a connection to a Redis server.
+// Calls return once their context is done, the command they sent runs on until the ReadTimeout of C
 type Redis struct {
 	C *redis.Client
+	// Prefix is prepended to every key so several applications can share a server
+	Prefix string
+	feed   string
+	size   int
 }
 
-// HealthCheck checks if our redis server is up and running
-func (r Redis) HealthCheck() (string, error) {
-	response, err := r.C.Ping().Result()
-	return response, err
+// WithFeed returns a copy of the client scoped to a feed
+func (r Redis) WithFeed(name string, size int) Backend {
+	r.feed = name
+	r.size = size
+	return r
+}
+
+// dataKey is the sorted set holding the users events
+func (r Redis) dataKey(user string) string {
+	return key(r.Prefix, r.feed, user) + ".data"
 }
 
-// Store stores an event for a user
-func (r Redis) Store(user, value string, at int64) (int64, error) {
-	userData := user + ".data"
-	// add event
-	addResponse, err := r.C.ZAdd(userData, redis.Z{Score: float64(at), Member: value}).Result()
+// metaKey is the hash holding the users counters and last read time stamp
+func (r Redis) metaKey(user string) string {
+	return key(r.Prefix, r.feed, user) + ".meta"
+}
 
-	if err != nil {
-		log.Println(err)
-		return 0, err
+// payloadKey is the hash holding the values of the users events by their ID
+func (r Redis) payloadKey(user string) string {
+	return key(r.Prefix, r.feed, user) + ".payload"
+}
+
+// flagsKey is the hash holding the read flags of the users events by their ID
+func (r Redis) flagsKey(user string) string {
+	return key(r.Prefix, r.feed, user) + ".flags"
+}
+
+// groupsKey is the hash holding the groups of the users aggregated events
+func (r Redis) groupsKey(user string) string {
+	return key(r.Prefix, r.feed, user) + ".groups"
+}
+
+// timesKey is the hash holding the times of the users ranked events by their ID
+func (r Redis) timesKey(user string) string {
+	return key(r.Prefix, r.feed, user) + ".times"
+}
+
+// pinsKey is the hash holding the time the users pinned events are pinned until by their ID
+func (r Redis) pinsKey(user string) string {
+	return key(r.Prefix, r.feed, user) + ".pins"
+}
+
+// prioritiesKey is the hash holding the priorities of the users events by their ID
+func (r Redis) prioritiesKey(user string) string {
+	return key(r.Prefix, r.feed, user) + ".priorities"
+}
+
+// keys are the keys of the user the scripts run on
+func (r Redis) keys(user string) []string {
+	return []string{
+		r.dataKey(user), r.metaKey(user), r.payloadKey(user), r.flagsKey(user),
+		r.groupsKey(user), r.timesKey(user), r.pinsKey(user), r.prioritiesKey(user),
+	}
+}
+
+// call runs fn on the connection of r bound to ctx, see call
+func (r Redis) call(ctx context.Context, fn func(c *redis.Client) error) error {
+	return call(ctx, r.C, fn)
+}
+
+// call runs fn on c bound to ctx and stops waiting for it once ctx is done. go-redis keeps the
+// context of a client without handing it to the connection, so a call that outlives its context
+// is left to finish in the background, bounded by the ReadTimeout of c, and its reply dropped.
+// fn must only write to variables the caller reads when call returns the error of fn
+func call(ctx context.Context, c *redis.Client, fn func(c *redis.Client) error) error {
+	if ctx.Done() == nil {
+		return fn(c.WithContext(ctx))
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- fn(c.WithContext(ctx))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return contextError(ctx)
+	}
+}
+
+// fail wraps an error of the connection with the operation, feed and user it happened on
+func (r Redis) fail(ctx context.Context, op, user string, err error) error {
+	return opError(op, r.feed, user, backendError(ctx, err))
+}
+
+// Close closes the connection pool of C, it is shared by every copy of the backend
+func (r Redis) Close() error {
+	return r.C.Close()
+}
+
+// HealthCheck checks if our redis server is up and running
+func (r Redis) HealthCheck(ctx context.Context) (string, error) {
+	if err := contextError(ctx); err != nil {
+		return "", r.fail(ctx, "health check", "", err)
 	}
-	maxSize := int64(17)
 
-	// check if events aren't exceeding 17 (if 18 remove the oldest event) and response will be 1
-	// and events should now be 17
-	removeResponse, err := r.C.ZRemRangeByRank(userData, 0, -maxSize-1).Result()
+	var response string
+	err := r.call(ctx, func(c *redis.Client) (err error) {
+		response, err = c.Ping().Result()
+		return err
+	})
 
 	if err != nil {
-		log.Println(err)
-		return 0, err
+		return "", r.fail(ctx, "health check", "", err)
 	}
 
-	// increase
-	userMeta := user + ".meta"
+	return response, nil
+}
+
+// Store stores an event for a user with its value as its ID, storing the value again moves
+// the event. The set is trimmed to the feed size and the counters are updated in the same round trip
+func (r Redis) Store(ctx context.Context, user, value string, at int64) (int64, error) {
+	return r.StoreID(ctx, user, value, value, at, float64(at))
+}
+
+// StoreID stores an event for a user under its ID scored score, the set is trimmed to the feed
+// size and the counters are updated in the same round trip
+func (r Redis) StoreID(ctx context.Context, user, id, value string, at int64, score float64) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, r.fail(ctx, "store", user, err)
+	}
 
-	change := addResponse - removeResponse
-	_, err = r.C.HIncrBy(userMeta, "total_count", change).Result()
+	var change int64
+	err := r.call(ctx, func(c *redis.Client) (err error) {
+		change, err = storeScript.Run(c, r.keys(user), score, id, feedSize(r.size), value, at, time.Now().Unix()).Int64()
+		return err
+	})
 
 	if err != nil {
-		log.Println(err)
-		return 0, err
+		return 0, r.fail(ctx, "store", user, err)
+	}
+
+	return change, nil
+}
+
+// StoreGroup adds an event to the group open for key when the group started within window
+// seconds, otherwise it stores the event under id as a new group. Returns the change to the
+// number of events like StoreID
+func (r Redis) StoreGroup(ctx context.Context, user, id, value string, at int64, score float64, key, actor string, window int64) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, r.fail(ctx, "store", user, err)
 	}
 
-	_, err = r.C.HIncrBy(userMeta, "unread_count", change).Result()
+	var change int64
+	err := r.call(ctx, func(c *redis.Client) (err error) {
+		change, err = groupScript.Run(c, r.keys(user), score, id, feedSize(r.size), value, at, time.Now().Unix(), key, actor, window).Int64()
+		return err
+	})
 
 	if err != nil {
-		log.Println(err)
-		return 0, err
+		return 0, r.fail(ctx, "store", user, err)
 	}
 
 	return change, nil
 }
 
-// Delete removes an event for a user
-func (r Redis) Delete(user, value string, at int64) (int64, error) {
-	userData := user + ".data"
+// Delete removes the event stored with value as its ID and updates the counters
+func (r Redis) Delete(ctx context.Context, user, value string, at int64) (int64, error) {
+	return r.change(ctx, "delete", user, deleteScript, value)
+}
+
+// DeleteID removes an event and updates the counters, reporting whether the event existed
+func (r Redis) DeleteID(ctx context.Context, user, id string) (bool, error) {
+	result, err := r.change(ctx, "delete", user, deleteScript, id)
+	return result > 0, err
+}
+
+// Update replaces the value of an event in place, reporting whether the event existed
+func (r Redis) Update(ctx context.Context, user, id, value string) (bool, error) {
+	result, err := r.change(ctx, "update", user, updateScript, id, value)
+	return result > 0, err
+}
+
+// Move moves an event to a new time and updates the unread count, reporting whether the event existed
+func (r Redis) Move(ctx context.Context, user, id string, at int64) (bool, error) {
+	result, err := r.change(ctx, "move", user, moveScript, id, at)
+	return result > 0, err
+}
+
+// Pin pins an event until the time stamp until, 0 pins it until it is unpinned. Reports whether the event existed
+func (r Redis) Pin(ctx context.Context, user, id string, until int64) (bool, error) {
+	result, err := r.change(ctx, "pin", user, pinScript, id, until)
+	return result > 0, err
+}
+
+// Unpin unpins an event, reporting whether the event existed
+func (r Redis) Unpin(ctx context.Context, user, id string) (bool, error) {
+	result, err := r.change(ctx, "unpin", user, unpinScript, id)
+	return result > 0, err
+}
+
+// SetPriority sets the priority of an event, reporting whether the event existed
+func (r Redis) SetPriority(ctx context.Context, user, id string, priority int) (bool, error) {
+	result, err := r.change(ctx, "set priority", user, priorityScript, id, priority)
+	return result > 0, err
+}
+
+// Rescore scores events anew by their ID and returns the number of events rescored
+func (r Redis) Rescore(ctx context.Context, user string, scores map[string]float64) (int64, error) {
+	if err := contextError(ctx); err != nil || len(scores) == 0 {
+		return 0, r.fail(ctx, "rescore", user, err)
+	}
+
+	args := make([]interface{}, 0, 2*len(scores))
+	for id, score := range scores {
+		args = append(args, id, score)
+	}
+
+	return r.change(ctx, "rescore", user, rescoreScript, args...)
+}
+
+// change runs a script changing a single event of the user
+func (r Redis) change(ctx context.Context, op, user string, script *redis.Script, args ...interface{}) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, r.fail(ctx, op, user, err)
+	}
 
-	result, err := r.C.ZRem(userData, value).Result()
+	var result int64
+	err := r.call(ctx, func(c *redis.Client) (err error) {
+		result, err = script.Run(c, r.keys(user), args...).Int64()
+		return err
+	})
 
 	if err != nil {
-		log.Printf("remove item %s \n", err)
-		return 0, err
+		return 0, r.fail(ctx, op, user, err)
 	}
 
 	return result, nil
 }
 
-// Wipe wipes the users feed
-func (r Redis) Wipe(user string) (int64, error) {
-	userData := user + ".data"
-
+// Wipe wipes the users feed, meta and payloads and returns the number of events removed
+func (r Redis) Wipe(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, r.fail(ctx, "wipe", user, err)
+	}
 
-	response, err := r.C.Del(userData).Result()
+	var response int64
+	err := r.call(ctx, func(c *redis.Client) (err error) {
+		response, err = wipeScript.Run(c, r.keys(user)).Int64()
+		return err
+	})
 
 	if err != nil {
-		log.Printf("error failed: wipe delete events %s \n", err)
-		return 0, err
+		return 0, r.fail(ctx, "wipe", user, err)
 	}
 
-	return response, err
+	return response, nil
 }
 
-// Paginate paginates events for the user when peek is true
-// do not reset #last_read
-func (r Redis) Paginate(user string, page, perPage int) ([]string, error) {
-	userData := user + ".data"
+// Paginate paginates events for the user, pages start at one
+func (r Redis) Paginate(ctx context.Context, user string, page, perPage int) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, r.fail(ctx, "paginate", user, err)
+	}
+
+	if page < 1 || perPage < 1 {
+		return nil, r.fail(ctx, "paginate", user, fmt.Errorf("%w: page %d of %d", ErrInvalidPage, page, perPage))
+	}
 
 	from := (page - 1) * perPage
 	to := (page * perPage) - 1
 
-	results, err := r.C.ZRevRange(userData, int64(from), int64(to)).Result()
+	return r.items(ctx, "paginate", user, r.rank(user, from, to, true))
+}
+
+// All returns all events for the user
+func (r Redis) All(ctx context.Context, user string) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, r.fail(ctx, "all", user, err)
+	}
+
+	return r.items(ctx, "all", user, r.rank(user, 0, -1, true))
+}
+
+// After returns up to limit events older than the cursor, newest first
+func (r Redis) After(ctx context.Context, user string, cursor Cursor, limit int) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, r.fail(ctx, "after", user, err)
+	}
+
+	if limit < 1 {
+		return nil, r.fail(ctx, "after", user, fmt.Errorf("%w: limit %d", ErrInvalidPage, limit))
+	}
 
+	if cursor.IsZero() {
+		return r.items(ctx, "after", user, r.rank(user, 0, limit-1, false))
+	}
 
-	return results, err
+	return r.items(ctx, "after", user, func(pipe redis.Pipeliner) func() ([]stored, error) {
+		cmd := afterScript.EvalSha(pipe, r.keys(user), cursor.Score, limit)
 
+		return func() ([]stored, error) {
+			events, err := readStored(cmd)
+			return filterStored(events, limit, cursor.older), err
+		}
+	})
 }
 
-// All returns all events for the user
-func (r Redis) All(user string) ([]string, error) {
-	userData := user + ".data"
-	events, err := r.C.ZRevRange(userData, 0, -1).Result()
-	return events, err
+// Before returns up to limit events newer than the cursor, newest first
+func (r Redis) Before(ctx context.Context, user string, cursor Cursor, limit int) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, r.fail(ctx, "before", user, err)
+	}
+
+	if limit < 1 {
+		return nil, r.fail(ctx, "before", user, fmt.Errorf("%w: limit %d", ErrInvalidPage, limit))
+	}
+
+	if cursor.IsZero() {
+		return nil, r.fail(ctx, "before", user, fmt.Errorf("%w: nothing is newer than the newest event", ErrInvalidCursor))
+	}
+
+	return r.items(ctx, "before", user, func(pipe redis.Pipeliner) func() ([]stored, error) {
+		cmd := beforeScript.EvalSha(pipe, r.keys(user), cursor.Score, limit)
+
+		return func() ([]stored, error) {
+			events, err := readStored(cmd)
+			events = filterStored(events, limit, cursor.newer)
+
+			// the script reads oldest first
+			for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+				events[i], events[j] = events[j], events[i]
+			}
+
+			return events, err
+		}
+	})
+}
+
+// Between returns the events that happened at or after from and before to in the order of the feed
+func (r Redis) Between(ctx context.Context, user string, from, to int64) ([]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, r.fail(ctx, "between", user, err)
+	}
+
+	return r.items(ctx, "between", user, func(pipe redis.Pipeliner) func() ([]stored, error) {
+		cmd := betweenScript.EvalSha(pipe, r.keys(user), from, to)
+
+		return func() ([]stored, error) {
+			return readStored(cmd)
+		}
+	})
+}
+
+// Merge reads the latest limit events of the user across feeds in one round trip, every event
+// when limit is 0. The feeds must all be Redis feeds on the connection of r
+func (r Redis) Merge(ctx context.Context, user string, feeds []Backend, limit int) ([][]Item, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, r.fail(ctx, "merge", user, err)
+	}
+
+	merged := make([]Redis, len(feeds))
+
+	for i, feed := range feeds {
+		other, ok := feed.(Redis)
+		if !ok || other.C != r.C {
+			return nil, r.fail(ctx, "merge", user, fmt.Errorf("%w: merging feeds of other connections", ErrNotSupported))
+		}
+		merged[i] = other
+	}
+
+	items := make([][]Item, len(merged))
+
+	readers, err := r.pipelined(ctx, []string{user}, func(pipe redis.Pipeliner, user string) reader {
+		lastReads := make([]*redis.StringCmd, len(merged))
+		keys := make([]string, 0, 8*len(merged))
+
+		for i, feed := range merged {
+			lastReads[i] = pipe.HGet(feed.metaKey(user), "last_read")
+			keys = append(keys, feed.keys(user)...)
+		}
+
+		cmd := mergeScript.EvalSha(pipe, keys, limit)
+
+		return func() (Result, error) {
+			for i := range merged {
+				events := func() ([]stored, error) {
+					reply, err := cmd.Result()
+
+					if err != nil {
+						return nil, err
+					}
+
+					replies, ok := reply.([]interface{})
+
+					if !ok || len(replies) != len(merged) {
+						return nil, fmt.Errorf("feeder: unexpected script reply %v", reply)
+					}
+
+					return parseStored(replies[i])
+				}
+
+				var err error
+				if items[i], err = readItems(events, lastReads[i]); err != nil {
+					return Result{}, err
+				}
+			}
+			return Result{}, nil
+		}
+	})
+
+	if err != nil {
+		return nil, r.fail(ctx, "merge", user, err)
+	}
+
+	if _, err := readers[0](); err != nil {
+		return nil, r.fail(ctx, "merge", user, err)
+	}
+
+	return items, nil
+}
+
+// query queues reading events in a pipeline and returns the function reading its reply
+type query func(pipe redis.Pipeliner) func() ([]stored, error)
+
+// rank queries the events of the user ranked from to to, newest first and after the pinned
+// events when pins is set
+func (r Redis) rank(user string, from, to int, pins bool) query {
+	return func(pipe redis.Pipeliner) func() ([]stored, error) {
+		args := []interface{}{from, to}
+		if pins {
+			args = append(args, time.Now().Unix())
+		}
+
+		cmd := rangeScript.EvalSha(pipe, r.keys(user), args...)
+
+		return func() ([]stored, error) {
+			return readStored(cmd)
+		}
+	}
+}
+
+// items reads the events selected by q along with the users last read time stamp
+// in one round trip
+func (r Redis) items(ctx context.Context, op, user string, q query) ([]Item, error) {
+	readers, err := r.pipelined(ctx, []string{user}, func(pipe redis.Pipeliner, user string) reader {
+		return r.queueItems(pipe, user, q)
+	})
+
+	if err != nil {
+		return nil, r.fail(ctx, op, user, err)
+	}
+
+	result, err := readers[0]()
+
+	if err != nil {
+		return nil, r.fail(ctx, op, user, err)
+	}
+
+	return result.Events, nil
+}
+
+// queueItems queues reading the users last read time stamp along with the events selected by q
+func (r Redis) queueItems(pipe redis.Pipeliner, user string, q query) reader {
+	lastRead := pipe.HGet(r.metaKey(user), "last_read")
+	events := q(pipe)
+
+	return func() (Result, error) {
+		items, err := readItems(events, lastRead)
+		return Result{Events: items}, err
+	}
 }
 
 // Count return the total count of events of user
-func (r Redis) Count(user string) (int, error) {
-	userMeta := user + ".meta"
-	response, err := r.C.HGet(userMeta, "total_count").Result()
+func (r Redis) Count(ctx context.Context, user string) (int, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, r.fail(ctx, "count", user, err)
+	}
+
+	userMeta := r.metaKey(user)
+	var response string
+	err := r.call(ctx, func(c *redis.Client) (err error) {
+		response, err = c.HGet(userMeta, "total_count").Result()
+		return err
+	})
+
+	if err == redis.Nil {
+		return 0, r.fail(ctx, "count", user, ErrUserNotFound)
+	}
+
+	if err != nil {
+		return 0, r.fail(ctx, "count", user, err)
+	}
+
 	count, err := strconv.Atoi(response)
 
+	if err != nil {
+		return 0, r.fail(ctx, "count", user, fmt.Errorf("%w: total_count %q", ErrCorruptMeta, response))
+	}
+
+	return count, nil
+
+}
+
+// UnreadCount returns the unread count kept in the meta hash, one HGET
+func (r Redis) UnreadCount(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, r.fail(ctx, "unread count", user, err)
+	}
+
+	var count int64
+	err := r.call(ctx, func(c *redis.Client) (err error) {
+		count, err = readUnreadCount(c.HGet(r.metaKey(user), "unread_count"))
+		return err
+	})
+
+	if err != nil {
+		return 0, r.fail(ctx, "unread count", user, err)
+	}
+
+	return count, nil
+}
+
+// readUnreadCount reads the unread count of a meta hash
+func readUnreadCount(cmd *redis.StringCmd) (int64, error) {
+	response, err := cmd.Result()
+
+	if err == redis.Nil {
+		return 0, ErrUserNotFound
+	}
+
 	if err != nil {
 		return 0, err
 	}
-	return count, err
 
+	count, err := strconv.ParseInt(response, 10, 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("%w: unread_count %q", ErrCorruptMeta, response)
+	}
+
+	return count, nil
+}
+
+// RecalculateUnread recounts the unread events from the data set, the flags and the last read
+// time stamp into the unread count and returns it
+func (r Redis) RecalculateUnread(ctx context.Context, user string) (int64, error) {
+	return r.change(ctx, "recalculate unread", user, recountScript)
 }
 
 // LastRead is LastRead when feed was last paginated
-func (r Redis) LastRead(user string) (int64, error) {
-	userMeta := user + ".meta"
-	response, err := r.C.HGet(userMeta, "last_read").Result()
+func (r Redis) LastRead(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, r.fail(ctx, "last read", user, err)
+	}
+
+	userMeta := r.metaKey(user)
+	var response string
+	err := r.call(ctx, func(c *redis.Client) (err error) {
+		response, err = c.HGet(userMeta, "last_read").Result()
+		return err
+	})
+
+	if err == redis.Nil {
+		return 0, r.fail(ctx, "last read", user, ErrUserNotFound)
+	}
 
 	if err != nil {
-		return 0, err
+		return 0, r.fail(ctx, "last read", user, err)
 	}
 
-	timestamp, err := strconv.Atoi(response)
+	timestamp, err := strconv.ParseInt(response, 10, 64)
 
 	if err != nil {
-		return 0, err
+		return 0, r.fail(ctx, "last read", user, fmt.Errorf("%w: last_read %q", ErrCorruptMeta, response))
 	}
 
-	return int64(timestamp), err
+	return timestamp, nil
 }
 
 // ResetLastRead resets last read time stamp
-// and also reset unread count
-func (r Redis) ResetLastRead(user string, at int64) (bool, error) {
-	userMeta := user + ".meta"
+func (r Redis) ResetLastRead(ctx context.Context, user string, at int64) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, r.fail(ctx, "reset last read", user, err)
+	}
 
-	res, err := r.C.HSet(userMeta, "last_read", at).Result()
+	var res int64
+	err := r.call(ctx, func(c *redis.Client) (err error) {
+		res, err = resetScript.Run(c, r.keys(user), at).Int64()
+		return err
+	})
 
-	return res, err
+	if err != nil {
+		return false, r.fail(ctx, "reset last read", user, err)
+	}
+
+	return res > 0, nil
+}
+
+// MarkRead flags events read and returns the number of events that were unread
+func (r Redis) MarkRead(ctx context.Context, user string, ids ...string) (int64, error) {
+	return r.mark(ctx, "mark read", user, "1", ids)
 }
 
-// UnRead returns the total count of un read feed items
-func (r Redis) UnRead(user string, at int64) (int64, error) {
-	userData := user + ".data"
-	positiveInf := strconv.FormatFloat(math.Inf(1), 'f', -1, 64)
-	lastReadTimeStamp := strconv.FormatInt(at, 10)
+// MarkUnread flags events unread and returns the number of events that were read
+func (r Redis) MarkUnread(ctx context.Context, user string, ids ...string) (int64, error) {
+	return r.mark(ctx, "mark unread", user, "0", ids)
+}
 
-	count, err := r.C.ZCount(userData, lastReadTimeStamp, positiveInf).Result()
+// mark runs markScript for the ids
+func (r Redis) mark(ctx context.Context, op, user, flag string, ids []string) (int64, error) {
+	if err := contextError(ctx); err != nil || len(ids) == 0 {
+		return 0, r.fail(ctx, op, user, err)
+	}
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, flag)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	return r.change(ctx, op, user, markScript, args...)
+}
+
+// MarkAllReadUpTo marks an event and every event before it read, reporting whether the event existed
+func (r Redis) MarkAllReadUpTo(ctx context.Context, user, id string) (bool, error) {
+	result, err := r.change(ctx, "mark read", user, markUpToScript, id)
+	return result > 0, err
+}
+
+// UnRead returns the total count of un read feed items against the last read at, events
+// flagged read or unread count by their flag
+func (r Redis) UnRead(ctx context.Context, user string, at int64) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, r.fail(ctx, "unread", user, err)
+	}
+
+	var count int64
+	err := r.call(ctx, func(c *redis.Client) (err error) {
+		count, err = unreadScript.Run(c, r.keys(user), at).Int64()
+		return err
+	})
 
 	if err != nil {
-		return 0, err
+		return 0, r.fail(ctx, "unread", user, err)
 	}
-	return count, err
+
+	return count, nil
 }
 
 // RecalculateCount count recalculates the length of events
-func (r Redis) RecalculateCount(user string) (int64, error) {
-	userMeta := user + ".meta"
-	userData := user + ".data"
+func (r Redis) RecalculateCount(ctx context.Context, user string) (int64, error) {
+	if err := contextError(ctx); err != nil {
+		return 0, r.fail(ctx, "recalculate count", user, err)
+	}
+
+	userMeta := r.metaKey(user)
+	userData := r.dataKey(user)
 
-	count, err := r.C.ZCard(userData).Result()
+	var count int64
+	err := r.call(ctx, func(c *redis.Client) (err error) {
+		count, err = c.ZCard(userData).Result()
+		return err
+	})
 
 	if err != nil {
-		return count, err
+		return 0, r.fail(ctx, "recalculate count", user, err)
 	}
 
-	res, err := r.C.HSet(userMeta, "total_count", count).Result()
+	var res bool
+	err = r.call(ctx, func(c *redis.Client) (err error) {
+		res, err = c.HSet(userMeta, "total_count", count).Result()
+		return err
+	})
+
+	if err != nil {
+		return 0, r.fail(ctx, "recalculate count", user, err)
+	}
 
 	var updated int64
 
@@ -186,7 +701,154 @@ func (r Redis) RecalculateCount(user string) (int64, error) {
 		updated = 0
 	}
 
-	return updated, err
+	return updated, nil
+}
+
+// stored is an event as it is kept in the data set, its ID and score, along with its value,
+// the time it happened at, its pin and its priority
+type stored struct {
+	id       string
+	score    float64
+	at       float64
+	value    string
+	flag     string
+	group    string
+	pinned   bool
+	until    int64
+	priority int
+}
+
+// readItems makes the items of events read along with the users last read time stamp,
+// a user that never read the feed has every event unread
+func readItems(events func() ([]stored, error), lastRead *redis.StringCmd) ([]Item, error) {
+	var since int64
+
+	// the last read time stamp is queued first, a connection lost while reading the replies
+	// only fails the first of them
+	if response, err := lastRead.Result(); err != redis.Nil {
+		if err != nil {
+			return nil, err
+		}
+
+		if since, err = strconv.ParseInt(response, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: last_read %q", ErrCorruptMeta, response)
+		}
+	}
+
+	read, err := events()
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+
+	items := make([]Item, len(read))
+	for i, e := range read {
+		if items[i], err = newItem(e.id, e.value, e.score, e.at, since).withFlag(e.flag).withGroup(e.group); err != nil {
+			return nil, err
+		}
+		items[i] = items[i].withPin(e.pinned, e.until, now)
+		items[i].Priority = e.priority
+	}
+
+	return items, nil
+}
+
+// readStored reads the reply of a script reading events, the IDs and scores as a flat list of
+// pairs followed by the payload, the read flag, the group state, the time, the pin and the
+// priority of each ID. Events without a payload are their own value and events without a time
+// happened at their score
+func readStored(cmd *redis.Cmd) ([]stored, error) {
+	reply, err := cmd.Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseStored(reply)
+}
+
+// parseStored parses one reply of with_values, see readStored
+func parseStored(reply interface{}) ([]stored, error) {
+	parts, ok := reply.([]interface{})
+
+	if !ok || len(parts) != 7 {
+		return nil, fmt.Errorf("feeder: unexpected script reply %v", reply)
+	}
+
+	pairs, _ := parts[0].([]interface{})
+	values, _ := parts[1].([]interface{})
+	flags, _ := parts[2].([]interface{})
+	groups, _ := parts[3].([]interface{})
+	times, _ := parts[4].([]interface{})
+	pins, _ := parts[5].([]interface{})
+	priorities, _ := parts[6].([]interface{})
+
+	if len(pairs)%2 != 0 || len(values) != len(pairs)/2 || len(flags) != len(values) || len(groups) != len(values) ||
+		len(times) != len(values) || len(pins) != len(values) || len(priorities) != len(values) {
+		return nil, fmt.Errorf("feeder: unexpected script reply %v", reply)
+	}
+
+	events := make([]stored, 0, len(values))
+
+	for i := 0; i < len(pairs); i += 2 {
+		id, _ := pairs[i].(string)
+		score, err := strconv.ParseFloat(fmt.Sprint(pairs[i+1]), 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("feeder: unexpected script reply %v", reply)
+		}
+
+		value, ok := values[i/2].(string)
+		if !ok {
+			value = id
+		}
+
+		at := score
+		if t, ok := times[i/2].(string); ok {
+			if at, err = strconv.ParseFloat(t, 64); err != nil {
+				return nil, fmt.Errorf("%w: time of %s %q", ErrCorruptMeta, id, t)
+			}
+		}
+
+		e := stored{id: id, score: score, at: at, value: value}
+		e.flag, _ = flags[i/2].(string)
+		e.group, _ = groups[i/2].(string)
+
+		if pin, ok := pins[i/2].(string); ok {
+			if e.until, err = strconv.ParseInt(pin, 10, 64); err != nil {
+				return nil, fmt.Errorf("%w: pin of %s %q", ErrCorruptMeta, id, pin)
+			}
+			e.pinned = true
+		}
+
+		if priority, ok := priorities[i/2].(string); ok {
+			if e.priority, err = strconv.Atoi(priority); err != nil {
+				return nil, fmt.Errorf("%w: priority of %s %q", ErrCorruptMeta, id, priority)
+			}
+		}
+
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+// filterStored keeps the first limit events that match keep
+func filterStored(events []stored, limit int, keep func(at float64, id string) bool) []stored {
+	kept := events[:0]
+
+	for _, e := range events {
+		if len(kept) == limit {
+			break
+		}
+		if keep(e.score, e.id) {
+			kept = append(kept, e)
+		}
+	}
+
+	return kept
 }
 
 // NewRedisClient returns an instance of the Redis struct
@@ -197,3 +859,35 @@ func NewRedisClient(c *redis.Client) Backend {
 
 	return client
 }
+
+// NewRedisClientWithPrefix returns an instance of the Redis struct whose keys all start with prefix
+func NewRedisClientWithPrefix(c *redis.Client, prefix string) Backend {
+	client := Redis{
+		C:      c,
+		Prefix: prefix,
+	}
+
+	return client
+}
+
+// NewRedisClientContext returns an instance of the Redis struct once the server
+// answered a health check made within the deadline of ctx
+func NewRedisClientContext(ctx context.Context, c *redis.Client) (Backend, error) {
+	client := NewRedisClient(c)
+
+	if _, err := client.HealthCheck(ctx); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// openRedis is the driver connecting to the Redis server described by config
+func openRedis(config Config) (Backend, error) {
+	opt, err := config.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRedisClient(redis.NewClient(opt)), nil
+}
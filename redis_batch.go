@@ -0,0 +1,247 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// reader reads the replies of the commands a batch queued for one user
+type reader func() (Result, error)
+
+// StoreBatch stores an event under its ID scored score for every user in one round trip
+func (r Redis) StoreBatch(ctx context.Context, users []string, id, value string, at int64, score float64) map[string]Result {
+	now := time.Now().Unix()
+
+	return r.batch(ctx, "store", users, func(pipe redis.Pipeliner, user string) reader {
+		cmd := storeScript.EvalSha(pipe, r.keys(user), score, id, feedSize(r.size), value, at, now)
+
+		return func() (Result, error) {
+			change, err := cmd.Int64()
+			return Result{Value: change}, err
+		}
+	})
+}
+
+// PaginateBatch paginates the events of every user in one round trip, pages start at one
+func (r Redis) PaginateBatch(ctx context.Context, users []string, page, perPage int) map[string]Result {
+	if page < 1 || perPage < 1 {
+		return r.failBatch(ctx, "paginate", users, fmt.Errorf("%w: page %d of %d", ErrInvalidPage, page, perPage))
+	}
+
+	from := (page - 1) * perPage
+	to := (page * perPage) - 1
+
+	return r.batch(ctx, "paginate", users, func(pipe redis.Pipeliner, user string) reader {
+		return r.queueItems(pipe, user, r.rank(user, from, to, true))
+	})
+}
+
+// CountBatch returns the total count of events of every user in one round trip
+func (r Redis) CountBatch(ctx context.Context, users []string) map[string]Result {
+	return r.batch(ctx, "count", users, func(pipe redis.Pipeliner, user string) reader {
+		cmd := pipe.HGet(r.metaKey(user), "total_count")
+
+		return func() (Result, error) {
+			response, err := cmd.Result()
+
+			if err != nil {
+				return Result{}, err
+			}
+
+			count, err := strconv.ParseInt(response, 10, 64)
+
+			if err != nil {
+				return Result{}, fmt.Errorf("%w: total_count %q", ErrCorruptMeta, response)
+			}
+
+			return Result{Value: count}, nil
+		}
+	})
+}
+
+// UnreadCountBatch reads the unread count of every user in one round trip, users without
+// a meta hash have none
+func (r Redis) UnreadCountBatch(ctx context.Context, users []string) map[string]Result {
+	return r.batch(ctx, "unread count", users, func(pipe redis.Pipeliner, user string) reader {
+		cmd := pipe.HGet(r.metaKey(user), "unread_count")
+
+		return func() (Result, error) {
+			count, err := readUnreadCount(cmd)
+			if err == ErrUserNotFound {
+				return Result{}, nil
+			}
+			return Result{Value: count}, err
+		}
+	})
+}
+
+// ResetLastReadBatch resets the last read time stamp of every user in one round trip
+func (r Redis) ResetLastReadBatch(ctx context.Context, users []string, at int64) map[string]Result {
+	return r.batch(ctx, "reset last read", users, func(pipe redis.Pipeliner, user string) reader {
+		cmd := resetScript.EvalSha(pipe, r.keys(user), at)
+
+		return func() (Result, error) {
+			return Result{}, cmd.Err()
+		}
+	})
+}
+
+// WipeBatch wipes the feed and meta of every user in one round trip
+func (r Redis) WipeBatch(ctx context.Context, users []string) map[string]Result {
+	return r.batch(ctx, "wipe", users, func(pipe redis.Pipeliner, user string) reader {
+		cmd := wipeScript.EvalSha(pipe, r.keys(user))
+
+		return func() (Result, error) {
+			removed, err := cmd.Int64()
+			return Result{Value: removed}, err
+		}
+	})
+}
+
+// batch queues the commands of every user, sends them in a single pipeline and reads
+// the replies into the users Result
+func (r Redis) batch(ctx context.Context, op string, users []string,
+	queue func(pipe redis.Pipeliner, user string) reader) map[string]Result {
+
+	if err := contextError(ctx); err != nil {
+		return r.failBatch(ctx, op, users, err)
+	}
+
+	results := make(map[string]Result, len(users))
+
+	if len(users) == 0 {
+		return results
+	}
+
+	readers, err := r.pipelined(ctx, users, queue)
+
+	if err != nil {
+		return r.failBatch(ctx, op, users, err)
+	}
+
+	// a connection lost while the replies are read leaves the commands after it without
+	// a reply, they fail with the same error
+	var lost error
+
+	for i, user := range users {
+		if lost != nil {
+			results[user] = Result{Err: r.fail(ctx, op, user, lost)}
+			continue
+		}
+
+		result, err := readers[i]()
+
+		if err == redis.Nil {
+			err = ErrUserNotFound
+		}
+
+		if err != nil {
+			if connectionLost(ctx, err) {
+				lost = err
+			}
+			result = Result{Err: r.fail(ctx, op, user, err)}
+		}
+
+		results[user] = result
+	}
+
+	return results
+}
+
+// pipelined sends the commands queued for every user in one round trip, when the server does
+// not know the scripts yet they are loaded and the commands sent again
+func (r Redis) pipelined(ctx context.Context, users []string,
+	queue func(pipe redis.Pipeliner, user string) reader) ([]reader, error) {
+
+	var readers []reader
+
+	// the commands of a pipeline that was sent are read even when some of them failed
+	exec := func() ([]redis.Cmder, error) {
+		var cmds []redis.Cmder
+
+		err := r.call(ctx, func(c *redis.Client) (err error) {
+			readers = make([]reader, 0, len(users))
+
+			cmds, err = c.Pipelined(func(pipe redis.Pipeliner) error {
+				for _, user := range users {
+					readers = append(readers, queue(pipe, user))
+				}
+				return nil
+			})
+
+			if len(cmds) == 0 {
+				return err
+			}
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return cmds, nil
+	}
+
+	cmds, err := exec()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !noScript(cmds) {
+		return readers, nil
+	}
+
+	err = r.call(ctx, func(c *redis.Client) error {
+		_, err := c.Pipelined(func(pipe redis.Pipeliner) error {
+			for _, script := range scripts {
+				script.Load(pipe)
+			}
+			return nil
+		})
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = exec(); err != nil {
+		return nil, err
+	}
+
+	return readers, nil
+}
+
+// failBatch fails the operation for every user with the same error
+func (r Redis) failBatch(ctx context.Context, op string, users []string, err error) map[string]Result {
+	results := make(map[string]Result, len(users))
+
+	for _, user := range users {
+		results[user] = Result{Err: r.fail(ctx, op, user, err)}
+	}
+
+	return results
+}
+
+// noScript reports whether a command failed because its script is not loaded on the server
+func noScript(cmds []redis.Cmder) bool {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+			return true
+		}
+	}
+	return false
+}
+
+// connectionLost reports whether err broke the connection rather than failing a single command
+func connectionLost(ctx context.Context, err error) bool {
+	err = backendError(ctx, err)
+	return errors.Is(err, ErrTimeout) || errors.Is(err, ErrBackendUnavailable) || contextError(ctx) != nil
+}
@@ -0,0 +1,186 @@
+package feeder
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// RedisGraph is a Graph kept in Redis. Every user has a sorted set of the users following it and
+// one of the users it follows, both scored by when the follow was made
+type RedisGraph struct {
+	C *redis.Client
+	// Prefix is prepended to every key so several applications can share a server
+	Prefix string
+}
+
+// followersKey is the sorted set holding the users following user
+func (g RedisGraph) followersKey(user string) string {
+	return key(g.Prefix, "graph", user) + ".followers"
+}
+
+// followingKey is the sorted set holding the users user follows
+func (g RedisGraph) followingKey(user string) string {
+	return key(g.Prefix, "graph", user) + ".following"
+}
+
+// call runs fn on the connection of g bound to ctx, see call
+func (g RedisGraph) call(ctx context.Context, fn func(c *redis.Client) error) error {
+	return call(ctx, g.C, fn)
+}
+
+// fail wraps an error of the connection with the operation and user it happened on
+func (g RedisGraph) fail(ctx context.Context, op, user string, err error) error {
+	return opError(op, "", user, backendError(ctx, err))
+}
+
+// Follow makes follower follow followee and reports whether it did not already
+func (g RedisGraph) Follow(ctx context.Context, follower, followee string) (bool, error) {
+	return g.change(ctx, "follow", followScript, follower, followee, time.Now().Unix())
+}
+
+// Unfollow stops follower following followee and reports whether it did
+func (g RedisGraph) Unfollow(ctx context.Context, follower, followee string) (bool, error) {
+	return g.change(ctx, "unfollow", unfollowScript, follower, followee)
+}
+
+// change runs a script changing the follow of follower and followee
+func (g RedisGraph) change(ctx context.Context, op string, script *redis.Script, follower, followee string, args ...interface{}) (bool, error) {
+	if err := contextError(ctx); err != nil {
+		return false, g.fail(ctx, op, follower, err)
+	}
+
+	if err := checkFollow(follower, followee); err != nil {
+		return false, g.fail(ctx, op, follower, err)
+	}
+
+	keys := []string{g.followingKey(follower), g.followersKey(followee)}
+	var result int64
+	err := g.call(ctx, func(c *redis.Client) (err error) {
+		result, err = script.Run(c, keys, append([]interface{}{follower, followee}, args...)...).Int64()
+		return err
+	})
+
+	if err != nil {
+		return false, g.fail(ctx, op, follower, err)
+	}
+
+	return result == 1, nil
+}
+
+// Followers paginates the users following user, the latest first
+func (g RedisGraph) Followers(ctx context.Context, user string, page, perPage int) ([]string, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, g.fail(ctx, "followers", user, err)
+	}
+
+	if page < 1 || perPage < 1 {
+		return nil, g.fail(ctx, "followers", user, fmt.Errorf("%w: page %d of %d", ErrInvalidPage, page, perPage))
+	}
+
+	from := int64((page - 1) * perPage)
+	to := int64(page*perPage) - 1
+
+	var followers []string
+	err := g.call(ctx, func(c *redis.Client) (err error) {
+		followers, err = c.ZRevRange(g.followersKey(user), from, to).Result()
+		return err
+	})
+
+	if err != nil {
+		return nil, g.fail(ctx, "followers", user, err)
+	}
+
+	return followers, nil
+}
+
+// FollowersAfter returns up to limit follows of the users following user made before after,
+// the latest first
+func (g RedisGraph) FollowersAfter(ctx context.Context, user string, after Follow, limit int) ([]Follow, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, g.fail(ctx, "followers after", user, err)
+	}
+
+	if limit < 1 {
+		return nil, g.fail(ctx, "followers after", user, fmt.Errorf("%w: limit %d", ErrInvalidPage, limit))
+	}
+
+	var reply []redis.Z
+	err := g.call(ctx, func(c *redis.Client) (err error) {
+		if after.IsZero() {
+			reply, err = c.ZRevRangeWithScores(g.followersKey(user), 0, int64(limit)-1).Result()
+			return err
+		}
+
+		// the follows of the second of the cursor made after it are read to be dropped
+		var scores interface{}
+		scores, err = followersAfterScript.Run(c, []string{g.followersKey(user)}, after.At, after.User, limit).Result()
+		if err != nil {
+			return err
+		}
+
+		reply, err = readFollows(scores)
+		return err
+	})
+
+	if err != nil {
+		return nil, g.fail(ctx, "followers after", user, err)
+	}
+
+	follows := make([]Follow, len(reply))
+	for i, z := range reply {
+		follows[i] = Follow{User: fmt.Sprint(z.Member), At: int64(z.Score)}
+	}
+
+	return follows, nil
+}
+
+// readFollows reads the followers and scores of followersAfterScript as a flat list of pairs
+func readFollows(reply interface{}) ([]redis.Z, error) {
+	pairs, ok := reply.([]interface{})
+
+	if !ok || len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("feeder: unexpected script reply %v", reply)
+	}
+
+	follows := make([]redis.Z, 0, len(pairs)/2)
+
+	for i := 0; i < len(pairs); i += 2 {
+		score, err := strconv.ParseFloat(fmt.Sprint(pairs[i+1]), 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("feeder: unexpected script reply %v", reply)
+		}
+
+		follows = append(follows, redis.Z{Member: pairs[i], Score: score})
+	}
+
+	return follows, nil
+}
+
+// Following returns every user that user follows, the latest first
+func (g RedisGraph) Following(ctx context.Context, user string) ([]string, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, g.fail(ctx, "following", user, err)
+	}
+
+	var following []string
+	err := g.call(ctx, func(c *redis.Client) (err error) {
+		following, err = c.ZRevRange(g.followingKey(user), 0, -1).Result()
+		return err
+	})
+
+	if err != nil {
+		return nil, g.fail(ctx, "following", user, err)
+	}
+
+	return following, nil
+}
+
+// NewRedisGraph returns a graph kept on the server of c
+func NewRedisGraph(c *redis.Client) Graph {
+	return RedisGraph{C: c}
+}
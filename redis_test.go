@@ -1,6 +1,12 @@
 package feeder
 
 import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +17,8 @@ import (
 
 func TestNewRedisClient(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 	if err != nil {
 		panic(err)
@@ -24,7 +32,7 @@ func TestNewRedisClient(t *testing.T) {
 	client := NewRedisClient(c)
 
 	want := "PONG"
-	got, err := client.HealthCheck()
+	got, err := client.HealthCheck(ctx)
 
 	if err != nil {
 		t.Errorf("health check error %s", err)
@@ -38,6 +46,8 @@ func TestNewRedisClient(t *testing.T) {
 
 func TestStore(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 	if err != nil {
 		panic(err)
@@ -55,7 +65,7 @@ func TestStore(t *testing.T) {
 	at := time.Now().Unix()
 
 	want := int64(1)
-	got, err := client.Store(user, value, at)
+	got, err := client.Store(ctx, user, value, at)
 
 	if err != nil {
 		t.Errorf("store action error %s", err)
@@ -69,6 +79,8 @@ func TestStore(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 	if err != nil {
 		panic(err)
@@ -85,10 +97,10 @@ func TestDelete(t *testing.T) {
 	user := "okandas"
 	value := "storing event for user"
 	at := time.Now().Unix()
-	client.Store(user, value, at)
+	client.Store(ctx, user, value, at)
 
 	want := int64(1)
-	got, err := client.Delete(user, value, at)
+	got, err := client.Delete(ctx, user, value, at)
 
 	if err != nil {
 		t.Errorf("error %s", err)
@@ -103,6 +115,8 @@ func TestDelete(t *testing.T) {
 
 func TestWipe(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 	if err != nil {
 		panic(err)
@@ -119,11 +133,11 @@ func TestWipe(t *testing.T) {
 	user := "okandas"
 	value := "storing event for user"
 	at := time.Now().Unix()
-	client.Store(user, value, at)
+	client.Store(ctx, user, value, at)
 
 	var want int64 = 1
 
-	got, err  := client.Wipe(user)
+	got, err  := client.Wipe(ctx, user)
 
 	if err != nil {
 		t.Errorf("error %s", err)
@@ -138,6 +152,8 @@ func TestWipe(t *testing.T) {
 
 func TestAll(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 	if err != nil {
 		panic(err)
@@ -154,14 +170,14 @@ func TestAll(t *testing.T) {
 	user := "okandas"
 	value := "storing first event for user"
 	at := time.Now().Unix()
-	client.Store(user, value, at)
+	client.Store(ctx, user, value, at)
 
 	value = "storing second event for user"
 	at = time.Now().Unix()
-	client.Store(user, value, at)
+	client.Store(ctx, user, value, at)
 
 	want := 2
-	got, err := client.All(user)
+	got, err := client.All(ctx, user)
 
 	if err != nil {
 		t.Errorf("error %s", err)
@@ -176,6 +192,8 @@ func TestAll(t *testing.T) {
 
 func TestPaginate(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 	if err != nil {
 		panic(err)
@@ -194,11 +212,11 @@ func TestPaginate(t *testing.T) {
 
 	for _, value := range values {
 		at := time.Now().Unix()
-		client.Store(user, value, at)
+		client.Store(ctx, user, value, at)
 	}
 
 	want := 5
-	got, err := client.Paginate(user, 1, 5)
+	got, err := client.Paginate(ctx, user, 1, 5)
 
 	if err != nil {
 		t.Errorf("error %s", err)
@@ -213,6 +231,8 @@ func TestPaginate(t *testing.T) {
 
 func TestTotalCount(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 	if err != nil {
 		panic(err)
@@ -231,11 +251,11 @@ func TestTotalCount(t *testing.T) {
 
 	for _, value := range values {
 		at := time.Now().Unix()
-		client.Store(user, value, at)
+		client.Store(ctx, user, value, at)
 	}
 
 	want := 6
-	got, err := client.Count(user)
+	got, err := client.Count(ctx, user)
 
 	if err != nil {
 		t.Errorf("error %s", err)
@@ -250,6 +270,8 @@ func TestTotalCount(t *testing.T) {
 
 func TestUnReadCount(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 	if err != nil {
 		panic(err)
@@ -268,7 +290,7 @@ func TestUnReadCount(t *testing.T) {
 	timeLastRead := time.Now().Add(3 * time.Second)
 
 
-	_, err = client.ResetLastRead(user, timeLastRead.Unix())
+	_, err = client.ResetLastRead(ctx, user, timeLastRead.Unix())
 
 	if err != nil {
 		t.Errorf("error %s", err)
@@ -281,10 +303,10 @@ func TestUnReadCount(t *testing.T) {
 		addTime := time.Duration(rand.Int())
 
 		at := time.Now().Add(addTime * time.Second).Unix()
-		client.Store(user, value, at)
+		client.Store(ctx, user, value, at)
 	}
 
-	lastRead, err := client.LastRead(user)
+	lastRead, err := client.LastRead(ctx, user)
 
 	if err != nil {
 		t.Errorf("error %s", err)
@@ -292,7 +314,7 @@ func TestUnReadCount(t *testing.T) {
 	}
 
 	var want int64 = 2
-	got, err := client.UnRead(user, lastRead)
+	got, err := client.UnRead(ctx, user, lastRead)
 
 	if err != nil {
 		t.Errorf("error %s", err)
@@ -307,6 +329,8 @@ func TestUnReadCount(t *testing.T) {
 
 func TestResetLastRead(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 	if err != nil {
 		panic(err)
@@ -324,7 +348,7 @@ func TestResetLastRead(t *testing.T) {
 
 
 	want := time.Now().Unix()
-	done, err := client.ResetLastRead(user, want)
+	done, err := client.ResetLastRead(ctx, user, want)
 
 	if err != nil {
 		t.Errorf("error %s", err)
@@ -338,7 +362,7 @@ func TestResetLastRead(t *testing.T) {
 		return
 	}
 
-	got, err  := client.LastRead(user)
+	got, err  := client.LastRead(ctx, user)
 
 	if err != nil {
 		t.Errorf("error %s", err)
@@ -354,6 +378,8 @@ func TestResetLastRead(t *testing.T) {
 
 func TestRecalculateCount(t *testing.T) {
 
+	ctx := context.Background()
+
 	server, err := miniredis.Run()
 
 	if err != nil {
@@ -374,13 +400,13 @@ func TestRecalculateCount(t *testing.T) {
 
 	for _, value := range values {
 		at := time.Now().Unix()
-		client.Store(user, value, at)
+		client.Store(ctx, user, value, at)
 	}
 
 	var want int64 = 0
 
 
-	got, err  := client.RecalculateCount(user)
+	got, err  := client.RecalculateCount(ctx, user)
 
 
 	if err != nil {
@@ -397,3 +423,328 @@ func TestRecalculateCount(t *testing.T) {
 
 
 
+
+func TestRecalculateUnread(t *testing.T) {
+
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	client := NewRedisClient(c).WithFeed("news", 5)
+
+	// test setup
+	user := "okandas"
+
+	for i := 0; i < 4; i++ {
+		client.Store(ctx, user, strconv.Itoa(i), int64(i))
+	}
+	client.ResetLastRead(ctx, user, 2)
+	client.(Redis).MarkUnread(ctx, user, "0")
+
+	// a counter that drifted, such as one kept before stores took reads into account
+	server.HSet("news:okandas.meta", "unread_count", "42")
+
+	got, err := client.RecalculateUnread(ctx, user)
+
+	if err != nil {
+		t.Errorf("error %s", err)
+		return
+	}
+
+	if got != 3 {
+		t.Errorf("recalculate unread got %d wanted %d", got, 3)
+	}
+
+	if count, _ := client.UnreadCount(ctx, user); count != 3 {
+		t.Errorf("unread count after recalculating got %d wanted %d", count, 3)
+	}
+
+	if got, _ := client.RecalculateUnread(ctx, "nobody"); got != 0 || server.Exists("news:nobody.meta") {
+		t.Errorf("recalculating a missing user got %d and should not make its meta", got)
+	}
+
+}
+
+func TestKeyLayout(t *testing.T) {
+
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	tt := []struct {
+		description string
+		client      Backend
+		want        []string
+	}{
+		{
+			description: "unscoped client keeps the user keys",
+			client:      NewRedisClient(c),
+			want:        []string{"okandas.data", "okandas.meta"},
+		},
+		{
+			description: "feed scoped client namespaces by feed",
+			client:      NewRedisClient(c).WithFeed("news", 5),
+			want:        []string{"news:okandas.data", "news:okandas.meta"},
+		},
+		{
+			description: "prefixed client namespaces by prefix and feed",
+			client:      NewRedisClientWithPrefix(c, "feeder").WithFeed("news", 5),
+			want:        []string{"feeder:news:okandas.data", "feeder:news:okandas.meta"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.description, func(t *testing.T) {
+			server.FlushAll()
+
+			tc.client.Store(ctx, "okandas", "storing event for user", time.Now().Unix())
+
+			for _, key := range tc.want {
+				if !server.Exists(key) {
+					t.Errorf("expected key %s to exist, got keys %v", key, server.Keys())
+				}
+			}
+		})
+	}
+
+}
+
+func TestCountersFollowData(t *testing.T) {
+
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	client := NewRedisClient(c).WithFeed("news", 3)
+
+	// test setup
+	user := "okandas"
+	values := []string{"first", "second", "third", "fourth", "fifth"}
+
+	for i, value := range values {
+		client.Store(ctx, user, value, int64(i))
+	}
+
+	// storing an event again only moves it and must not drift the counters
+	client.Store(ctx, user, "fourth", 10)
+	client.Delete(ctx, user, "fifth", 0)
+	client.Delete(ctx, user, "missing", 0)
+
+	members, _ := server.ZMembers("news:okandas.data")
+
+	got, err := client.Count(ctx, user)
+
+	if err != nil {
+		t.Errorf("error %s", err)
+		return
+	}
+
+	if got != len(members) {
+		t.Errorf("total count %d does not follow the %d events stored", got, len(members))
+	}
+
+	if unread := server.HGet("news:okandas.meta", "unread_count"); unread != "2" {
+		t.Errorf("unread count got %s wanted %d", unread, 2)
+	}
+
+	removed, err := client.Wipe(ctx, user)
+
+	if err != nil {
+		t.Errorf("error %s", err)
+		return
+	}
+
+	if removed != 2 {
+		t.Errorf("wipe removed %d events wanted %d", removed, 2)
+	}
+
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("wipe left keys behind %v", keys)
+	}
+
+}
+
+func TestConnectionTimeout(t *testing.T) {
+
+	// a server that accepts connections but never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	c := redis.NewClient(&redis.Options{
+		Addr:        listener.Addr().String(),
+		ReadTimeout: 50 * time.Millisecond,
+		MaxRetries:  0,
+	})
+
+	_, err = NewRedisClientContext(context.Background(), c)
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("got %v wanted %v", err, ErrTimeout)
+	}
+
+}
+
+func TestContextDeadline(t *testing.T) {
+
+	// a server that accepts connections but never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	defer listener.Close()
+
+	// the connections are closed once the test is done so the calls left running end
+	var mu sync.Mutex
+	var conns []net.Conn
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+
+	// the client never times out reads, only the context ends the calls
+	client := NewRedisClient(redis.NewClient(&redis.Options{
+		Addr:        listener.Addr().String(),
+		ReadTimeout: -1,
+		MaxRetries:  0,
+	}))
+
+	calls := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{"count", func(ctx context.Context) error {
+			_, err := client.Count(ctx, "okandas")
+			return err
+		}},
+		{"paginate", func(ctx context.Context) error {
+			_, err := client.Paginate(ctx, "okandas", 1, 10)
+			return err
+		}},
+		{"store", func(ctx context.Context) error {
+			_, err := client.Store(ctx, "okandas", "first", 1)
+			return err
+		}},
+	}
+
+	for _, c := range calls {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		err := c.call(ctx)
+		cancel()
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s returned after %s want the deadline of the context", c.name, elapsed)
+		}
+
+		if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s got %v wanted %v", c.name, err, ErrTimeout)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	if _, err := client.Count(ctx, "okandas"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v wanted %v", err, context.Canceled)
+	}
+
+}
+
+func TestErrors(t *testing.T) {
+
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr:       server.Addr(),
+		MaxRetries: 0,
+	})
+
+	client := NewRedisClient(c).WithFeed("news", 5)
+
+	// test setup
+	user := "okandas"
+	server.HSet("news:okandas.meta", "total_count", "many")
+	server.HSet("news:okandas.meta", "last_read", "yesterday")
+
+	if _, err := client.Count(ctx, user); !errors.Is(err, ErrCorruptMeta) {
+		t.Errorf("count got %v wanted %v", err, ErrCorruptMeta)
+	}
+
+	if _, err := client.LastRead(ctx, user); !errors.Is(err, ErrCorruptMeta) {
+		t.Errorf("last read got %v wanted %v", err, ErrCorruptMeta)
+	}
+
+	server.Close()
+
+	_, err = client.All(ctx, user)
+
+	if !errors.Is(err, ErrBackendUnavailable) {
+		t.Errorf("all on a closed server got %v wanted %v", err, ErrBackendUnavailable)
+	}
+
+	want := "feeder: all news user okandas: backend unavailable: "
+
+	if err != nil && !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("got message %q wanted it to start with %q", err, want)
+	}
+
+}
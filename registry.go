@@ -0,0 +1,96 @@
+package feeder
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry holds the feeds of an application by name, it is safe for concurrent use and its
+// zero value is an empty registry
+type Registry struct {
+	mu    sync.RWMutex
+	feeds map[string]*Feed
+}
+
+// Register adds a feed to the registry, a feed's name can only be registered once
+func (r *Registry) Register(feed *Feed) error {
+	if feed == nil || feed.Name == "" {
+		return fmt.Errorf("%w: a feed needs a name", ErrInvalidFeed)
+	}
+
+	if feed.P == nil {
+		return fmt.Errorf("%w: feed %s has no backend", ErrInvalidFeed, feed.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.feeds[feed.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrFeedExists, feed.Name)
+	}
+
+	if r.feeds == nil {
+		r.feeds = map[string]*Feed{}
+	}
+
+	r.feeds[feed.Name] = feed
+
+	return nil
+}
+
+// Feed looks up a feed by name
+func (r *Registry) Feed(name string) (*Feed, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	feed, ok := r.feeds[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFeedNotFound, name)
+	}
+
+	return feed, nil
+}
+
+// Remove removes a feed from the registry and reports whether it was registered
+func (r *Registry) Remove(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.feeds[name]
+	delete(r.feeds, name)
+
+	return ok
+}
+
+// Feeds returns the registered feeds ordered by name
+func (r *Registry) Feeds() []*Feed {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	feeds := make([]*Feed, 0, len(r.feeds))
+	for _, feed := range r.feeds {
+		feeds = append(feeds, feed)
+	}
+
+	sort.Slice(feeds, func(i, j int) bool { return feeds[i].Name < feeds[j].Name })
+
+	return feeds
+}
+
+// Activity returns the activity of a user in the named feed
+func (r *Registry) Activity(name, userID string) (*Activity, error) {
+	feed, err := r.Feed(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewActivity(userID, feed), nil
+}
+
+// NewRegistry instantiates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		feeds: map[string]*Feed{},
+	}
+}
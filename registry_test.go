@@ -0,0 +1,146 @@
+package feeder
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestRegistryRegister(t *testing.T) {
+
+	client := NewMemoryBackend()
+
+	tt := []struct {
+		description string
+		feed        *Feed
+		want        error
+	}{
+		{description: "registers a feed", feed: NewFeed("news", 5, 2, client), want: nil},
+		{description: "rejects a duplicate name", feed: NewFeed("news", 10, 2, client), want: ErrFeedExists},
+		{description: "rejects a feed without a name", feed: NewFeed("", 5, 2, client), want: ErrInvalidFeed},
+		{description: "rejects a feed without a backend", feed: NewFeed("alerts", 5, 2, nil), want: ErrInvalidFeed},
+		{description: "rejects a nil feed", feed: nil, want: ErrInvalidFeed},
+	}
+
+	registry := NewRegistry()
+
+	for _, tc := range tt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			got := registry.Register(tc.feed)
+
+			if !errors.Is(got, tc.want) {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+
+		})
+	}
+
+	feed, err := registry.Feed("news")
+
+	if err != nil {
+		t.Errorf("feed error %s", err)
+		return
+	}
+
+	if feed.Size != 5 {
+		t.Errorf("a duplicate replaced the registered feed, got size %d want %d", feed.Size, 5)
+	}
+
+}
+
+func TestRegistryZeroValue(t *testing.T) {
+
+	var registry Registry
+
+	if _, err := registry.Feed("news"); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("got %v want %v", err, ErrFeedNotFound)
+	}
+
+	if err := registry.Register(NewFeed("news", 5, 2, NewMemoryBackend())); err != nil {
+		t.Errorf("register error %s", err)
+		return
+	}
+
+	if feeds := registry.Feeds(); len(feeds) != 1 || feeds[0].Name != "news" {
+		t.Errorf("got %v want the news feed", feeds)
+	}
+
+}
+
+func TestRegistryFeeds(t *testing.T) {
+
+	client := NewMemoryBackend()
+	registry := NewRegistry()
+
+	registry.Register(NewFeed("notifications", 5, 2, client))
+	registry.Register(NewFeed("news", 5, 2, client))
+
+	feeds := registry.Feeds()
+
+	if len(feeds) != 2 || feeds[0].Name != "news" || feeds[1].Name != "notifications" {
+		t.Errorf("got %v want news and notifications", feeds)
+		return
+	}
+
+	if !registry.Remove("news") {
+		t.Errorf("removing a registered feed should report it was registered")
+	}
+
+	if registry.Remove("news") {
+		t.Errorf("removing a missing feed should report it was not registered")
+	}
+
+	if _, err := registry.Feed("news"); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("got %v want %v", err, ErrFeedNotFound)
+	}
+
+}
+
+func TestRegistryActivity(t *testing.T) {
+
+	client := NewMemoryBackend()
+	registry := NewRegistry()
+
+	feed := NewFeed("news", 5, 2, client)
+	registry.Register(feed)
+
+	activity, err := registry.Activity("news", "okandas")
+
+	if err != nil {
+		t.Errorf("activity error %s", err)
+		return
+	}
+
+	if activity.Feed != feed || activity.UserID != "okandas" {
+		t.Errorf("got activity %+v on the wrong feed or user", activity)
+	}
+
+	if _, err := registry.Activity("alerts", "okandas"); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("got %v want %v", err, ErrFeedNotFound)
+	}
+
+}
+
+func TestRegistryConcurrentRegister(t *testing.T) {
+
+	client := NewMemoryBackend()
+	registry := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			registry.Register(NewFeed("feed "+strconv.Itoa(i%10), 5, 2, client))
+			registry.Feeds()
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(registry.Feeds()); got != 10 {
+		t.Errorf("got %d feeds want %d", got, 10)
+	}
+
+}
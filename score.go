@@ -0,0 +1,86 @@
+package feeder
+
+import (
+	"math"
+	"time"
+)
+
+// Scorer ranks the events of a feed, the feed keeps its events highest score first and trims
+// the lowest. The score is taken when an event is stored and again when the feed is rescored,
+// now is the time it is taken at. Events keep the time they happened at apart from their score
+type Scorer interface {
+	Score(action Action, now time.Time) float64
+}
+
+// ScorerFunc is a function used as a Scorer
+type ScorerFunc func(action Action, now time.Time) float64
+
+// Score calls f
+func (f ScorerFunc) Score(action Action, now time.Time) float64 {
+	return f(action, now)
+}
+
+// Chronological scores an action by the second it happened at, the order of feeds without a Scorer
+type Chronological struct{}
+
+// Score returns the time stamp of the action
+func (Chronological) Score(action Action, now time.Time) float64 {
+	return float64(action.At.Unix())
+}
+
+// Decay scores recent actions highest, the score starts at 1 and halves every HalfLife since the
+// action happened. Scores taken at different times only compare once the feed is rescored, so
+// feeds ranked by decay are rescored regularly, see Activity.Rescore
+type Decay struct {
+	HalfLife time.Duration
+}
+
+// Score returns the decayed score of the action at now
+func (d Decay) Score(action Action, now time.Time) float64 {
+	if d.HalfLife <= 0 {
+		return 1
+	}
+	return math.Exp2(-float64(now.Sub(action.At)) / float64(d.HalfLife))
+}
+
+// Weighted multiplies the score of Scorer by the weight of the method of the action, methods
+// without a weight weigh 1. A nil Scorer is Chronological
+type Weighted struct {
+	Scorer  Scorer
+	Weights map[string]float64
+}
+
+// Score returns the weighted score of the action
+func (w Weighted) Score(action Action, now time.Time) float64 {
+	score := scoreOf(w.Scorer, action, now)
+
+	if weight, ok := w.Weights[action.Method]; ok {
+		return score * weight
+	}
+	return score
+}
+
+// Boosted adds the boost of an action, such as one kept in its Meta, to the score of Scorer.
+// A nil Scorer is Chronological and a nil Boost boosts nothing
+type Boosted struct {
+	Scorer Scorer
+	Boost  func(action Action) float64
+}
+
+// Score returns the boosted score of the action
+func (b Boosted) Score(action Action, now time.Time) float64 {
+	score := scoreOf(b.Scorer, action, now)
+
+	if b.Boost != nil {
+		score += b.Boost(action)
+	}
+	return score
+}
+
+// scoreOf scores an action with s, or by its time when s is nil
+func scoreOf(s Scorer, action Action, now time.Time) float64 {
+	if s == nil {
+		s = Chronological{}
+	}
+	return s.Score(action, now)
+}
@@ -0,0 +1,47 @@
+package feeder
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestScorers(t *testing.T) {
+
+	now := time.Unix(1700000000, 0)
+	boost := func(action Action) float64 {
+		if action.Meta["promoted"] == "true" {
+			return 10
+		}
+		return 0
+	}
+
+	tt := []struct {
+		description string
+		scorer      Scorer
+		action      Action
+		want        float64
+	}{
+		{description: "chronological", scorer: Chronological{}, action: Action{At: now.Add(-time.Hour)}, want: float64(now.Add(-time.Hour).Unix())},
+		{description: "decay of a new action", scorer: Decay{HalfLife: time.Hour}, action: Action{At: now}, want: 1},
+		{description: "decay after two half lives", scorer: Decay{HalfLife: time.Hour}, action: Action{At: now.Add(-2 * time.Hour)}, want: 0.25},
+		{description: "decay without a half life", scorer: Decay{}, action: Action{At: now.Add(-2 * time.Hour)}, want: 1},
+		{description: "weighted method", scorer: Weighted{Scorer: Decay{HalfLife: time.Hour}, Weights: map[string]float64{"mention": 3}}, action: Action{At: now.Add(-time.Hour), Method: "mention"}, want: 1.5},
+		{description: "unweighted method", scorer: Weighted{Scorer: Decay{HalfLife: time.Hour}, Weights: map[string]float64{"mention": 3}}, action: Action{At: now.Add(-time.Hour), Method: "like"}, want: 0.5},
+		{description: "weighted chronological", scorer: Weighted{Weights: map[string]float64{"mention": 2}}, action: Action{At: time.Unix(100, 0), Method: "mention"}, want: 200},
+		{description: "boosted", scorer: Boosted{Scorer: Decay{HalfLife: time.Hour}, Boost: boost}, action: Action{At: now, Meta: map[string]string{"promoted": "true"}}, want: 11},
+		{description: "not boosted", scorer: Boosted{Scorer: Decay{HalfLife: time.Hour}, Boost: boost}, action: Action{At: now}, want: 1},
+		{description: "func", scorer: ScorerFunc(func(action Action, now time.Time) float64 { return float64(len(action.Value)) }), action: Action{Value: "okandas"}, want: 7},
+	}
+
+	for _, tc := range tt {
+
+		t.Run(tc.description, func(t *testing.T) {
+
+			if got := tc.scorer.Score(tc.action, now); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,640 @@
+package feeder
+
+import "github.com/go-redis/redis"
+
+// The scripts below run server side so that the events in the data set, their payloads and
+// the counters in the meta hash are always changed together.
+//
+// KEYS[1] is the users data set, KEYS[2] the users meta hash, KEYS[3] the users payload hash,
+// KEYS[4] the users flags hash, KEYS[5] the users groups hash, KEYS[6] the users times hash,
+// KEYS[7] the users pins hash and KEYS[8] the users priorities hash.
+// The data set holds the IDs of the events, the payload hash their values by ID. Events stored
+// with their value as their ID have no payload.
+//
+// The data set scores the events by the time they happened at, or by the Scorer of a ranked
+// feed. The times hash holds the time of the events whose score is not their time by ID.
+//
+// The groups hash holds the state of the groups of an aggregated feed as JSON by ID, and the ID
+// of the group open for each method and object under "open:" followed by the group key.
+//
+// The pins hash holds the time each pinned event is pinned until by ID, 0 for until it is unpinned.
+// Pinned events come first and are not trimmed, the priorities hash holds the priority of the
+// events trimmed after others by ID.
+//
+// Events are read when they happened before the last_read watermark in the meta hash. The flags
+// hash holds the events marked otherwise, "1" for read and "0" for unread, by ID. Only events
+// whose flag differs from the watermark are flagged.
+
+// storeScript adds an event, trims the set to the feed size and moves the counters by the change.
+// The unread count moves by the read state of the event stored, replaced and trimmed.
+// ARGV[1] is the score, ARGV[2] the ID, ARGV[3] the feed size, ARGV[4] the value, ARGV[5] the time
+// and ARGV[6] the current time, pins expire by it
+var storeScript = redis.NewScript(unread + storing + `
+local added, unreadChange = add(ARGV[2], ARGV[1], ARGV[4], ARGV[5])
+local removed, trimmedUnread = trim(tonumber(ARGV[3]), tonumber(ARGV[6]))
+count(added - removed, unreadChange + trimmedUnread)
+return added - removed
+`)
+
+// groupScript adds an action to the group open for its method and object when the group started
+// within the window, moving the group to the action when it is the latest. Otherwise it opens a
+// group and stores it like storeScript. ARGV[1] to ARGV[6] are those of storeScript, ARGV[7] is
+// the group key, ARGV[8] the actor and ARGV[9] the window in seconds
+var groupScript = redis.NewScript(unread + storing + `
+local at = tonumber(ARGV[5])
+local open = 'open:' .. ARGV[7]
+local id = redis.call('HGET', KEYS[5], open)
+if id then
+	local state = redis.call('HGET', KEYS[5], id)
+	local score = redis.call('ZSCORE', KEYS[1], id)
+	local group = state and cjson.decode(state)
+	if score and group and at - group.first <= tonumber(ARGV[9]) then
+		local actors = {}
+		if ARGV[8] ~= '' then
+			actors[1] = ARGV[8]
+		end
+		for _, actor in ipairs(group.actors or {}) do
+			if actor ~= ARGV[8] then
+				actors[#actors + 1] = actor
+			end
+		end
+		group.actors = actors
+		group.count = group.count + 1
+		redis.call('HSET', KEYS[5], id, cjson.encode(group))
+		if at >= tonumber(time_of(id, score)) then
+			local _, unreadChange = add(id, ARGV[1], ARGV[4], ARGV[5])
+			count(0, unreadChange)
+		end
+		return 0
+	end
+end
+local group = {key = ARGV[7], first = at, count = 1}
+if ARGV[8] ~= '' then
+	group.actors = {ARGV[8]}
+end
+redis.call('HSET', KEYS[5], open, ARGV[2])
+redis.call('HSET', KEYS[5], ARGV[2], cjson.encode(group))
+local added, unreadChange = add(ARGV[2], ARGV[1], ARGV[4], ARGV[5])
+local removed, trimmedUnread = trim(tonumber(ARGV[3]), tonumber(ARGV[6]))
+count(added - removed, unreadChange + trimmedUnread)
+return added - removed
+`)
+
+// unread is the start of the scripts reading the read state of events. time_of returns the time
+// of the event id scored score. pinned returns the IDs of the events pinned at now as a set. watermark returns 1 when an event that happened at happened after
+// the last read, every event did when the user never read the feed. unread returns 1 when the
+// event id is unread, by its flag or else by the watermark. count_unread counts the unread events
+// against the last read lastRead, nil for never
+const unread = `
+local function time_of(id, score)
+	return redis.call('HGET', KEYS[6], id) or score
+end
+
+local function pinned(now)
+	local pins = redis.call('HGETALL', KEYS[7])
+	local ids = {}
+	for i = 1, #pins, 2 do
+		local expiry = tonumber(pins[i + 1])
+		if expiry == 0 or expiry > now then
+			ids[pins[i]] = true
+		end
+	end
+	return ids
+end
+
+local function watermark(at, lastRead)
+	if not lastRead or tonumber(at) >= tonumber(lastRead) then
+		return 1
+	end
+	return 0
+end
+
+local function unread(id, score)
+	local flag = redis.call('HGET', KEYS[4], id)
+	if flag then
+		return 1 - tonumber(flag)
+	end
+	return watermark(time_of(id, score), redis.call('HGET', KEYS[2], 'last_read'))
+end
+
+local function count_unread(lastRead)
+	local count = redis.call('ZCOUNT', KEYS[1], lastRead or '-inf', '+inf')
+	local times = redis.call('HGETALL', KEYS[6])
+	for i = 1, #times, 2 do
+		local score = redis.call('ZSCORE', KEYS[1], times[i])
+		if score and redis.call('HEXISTS', KEYS[4], times[i]) == 0 then
+			count = count + watermark(times[i + 1], lastRead) - watermark(score, lastRead)
+		end
+	end
+	local flags = redis.call('HGETALL', KEYS[4])
+	for i = 1, #flags, 2 do
+		local score = redis.call('ZSCORE', KEYS[1], flags[i])
+		if score then
+			count = count + (1 - tonumber(flags[i + 1])) - watermark(score, lastRead)
+		end
+	end
+	return count
+end
+`
+
+// storing is the start of the scripts storing and removing events, it follows unread. forget
+// removes the payloads, flags, groups, times, pins and priorities of ids. add stores the event id
+// scored score that happened at with its value and returns 1 when it is new along with the change
+// to the unread count. trim trims the data set to size events besides the events pinned at now,
+// lowest priority and then lowest score first, and returns the number removed along with the
+// change to the unread count. Expired pins are dropped. count moves the counters
+const storing = `
+local function forget(ids)
+	for _, id in ipairs(ids) do
+		local state = redis.call('HGET', KEYS[5], id)
+		if state then
+			local open = 'open:' .. cjson.decode(state).key
+			if redis.call('HGET', KEYS[5], open) == id then
+				redis.call('HDEL', KEYS[5], open)
+			end
+			redis.call('HDEL', KEYS[5], id)
+		end
+	end
+	redis.call('HDEL', KEYS[3], unpack(ids))
+	redis.call('HDEL', KEYS[4], unpack(ids))
+	redis.call('HDEL', KEYS[6], unpack(ids))
+	redis.call('HDEL', KEYS[7], unpack(ids))
+	redis.call('HDEL', KEYS[8], unpack(ids))
+end
+
+local function add(id, score, value, at)
+	local unreadChange = 0
+	local old = redis.call('ZSCORE', KEYS[1], id)
+	if old then
+		unreadChange = -unread(id, old)
+	end
+	if id ~= value then
+		redis.call('HSET', KEYS[3], id, value)
+	else
+		redis.call('HDEL', KEYS[3], id)
+	end
+	redis.call('HDEL', KEYS[4], id)
+	if tonumber(at) ~= tonumber(score) then
+		redis.call('HSET', KEYS[6], id, at)
+	else
+		redis.call('HDEL', KEYS[6], id)
+	end
+	local added = redis.call('ZADD', KEYS[1], score, id)
+	return added, unreadChange + unread(id, score)
+end
+
+local function trim(size, now)
+	if redis.call('EXISTS', KEYS[7]) == 0 and redis.call('EXISTS', KEYS[8]) == 0 then
+		local trimmed = redis.call('ZRANGE', KEYS[1], 0, -size - 1, 'WITHSCORES')
+		if #trimmed == 0 then
+			return 0, 0
+		end
+		local ids, unreadChange = {}, 0
+		for i = 1, #trimmed, 2 do
+			ids[#ids + 1] = trimmed[i]
+			unreadChange = unreadChange - unread(trimmed[i], trimmed[i + 1])
+		end
+		redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -size - 1)
+		forget(ids)
+		return #ids, unreadChange
+	end
+	local pins = pinned(now)
+	for _, id in ipairs(redis.call('HKEYS', KEYS[7])) do
+		if not pins[id] then
+			redis.call('HDEL', KEYS[7], id)
+		end
+	end
+	local events = redis.call('ZRANGE', KEYS[1], 0, -1, 'WITHSCORES')
+	local candidates = {}
+	for i = 1, #events, 2 do
+		if not pins[events[i]] then
+			local priority = tonumber(redis.call('HGET', KEYS[8], events[i]) or 0)
+			candidates[#candidates + 1] = {id = events[i], score = events[i + 1], priority = priority, rank = i}
+		end
+	end
+	if #candidates <= size then
+		return 0, 0
+	end
+	table.sort(candidates, function(a, b)
+		if a.priority ~= b.priority then
+			return a.priority < b.priority
+		end
+		return a.rank < b.rank
+	end)
+	local ids, unreadChange = {}, 0
+	for i = 1, #candidates - size do
+		ids[i] = candidates[i].id
+		unreadChange = unreadChange - unread(ids[i], candidates[i].score)
+	end
+	redis.call('ZREM', KEYS[1], unpack(ids))
+	forget(ids)
+	return #ids, unreadChange
+end
+
+local function count(change, unreadChange)
+	redis.call('HINCRBY', KEYS[2], 'total_count', change)
+	redis.call('HINCRBY', KEYS[2], 'unread_count', unreadChange)
+end
+`
+
+// deleteScript removes an event, moves the total count down and the unread count down when
+// the event was unread. ARGV[1] is the ID
+var deleteScript = redis.NewScript(unread + storing + `
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score then
+	return 0
+end
+local unreadChange = -unread(ARGV[1], score)
+redis.call('ZREM', KEYS[1], ARGV[1])
+forget({ARGV[1]})
+count(-1, unreadChange)
+return 1
+`)
+
+// updateScript replaces the value of an event, leaving it in place.
+// ARGV[1] is the ID and ARGV[2] the value
+var updateScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+if ARGV[1] == ARGV[2] then
+	redis.call('HDEL', KEYS[3], ARGV[1])
+else
+	redis.call('HSET', KEYS[3], ARGV[1], ARGV[2])
+end
+return 1
+`)
+
+// moveScript moves an event to a new time and moves the unread count when the event crosses the
+// users last read time stamp, flagged events keep their flag. Events scored by their time are
+// rescored, ranked events keep their score. ARGV[1] is the ID and ARGV[2] the time
+var moveScript = redis.NewScript(unread + `
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score then
+	return 0
+end
+local before = unread(ARGV[1], score)
+if redis.call('HEXISTS', KEYS[6], ARGV[1]) == 0 then
+	score = ARGV[2]
+	redis.call('ZADD', KEYS[1], score, ARGV[1])
+elseif tonumber(ARGV[2]) == tonumber(score) then
+	redis.call('HDEL', KEYS[6], ARGV[1])
+else
+	redis.call('HSET', KEYS[6], ARGV[1], ARGV[2])
+end
+redis.call('HINCRBY', KEYS[2], 'unread_count', unread(ARGV[1], score) - before)
+return 1
+`)
+
+// pinScript pins an event until ARGV[2], 0 for until it is unpinned. ARGV[1] is the ID
+var pinScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('HSET', KEYS[7], ARGV[1], ARGV[2])
+return 1
+`)
+
+// unpinScript unpins an event, the feed is trimmed back to its size by the next store.
+// ARGV[1] is the ID
+var unpinScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('HDEL', KEYS[7], ARGV[1])
+return 1
+`)
+
+// priorityScript sets the priority of an event to ARGV[2], events without one have priority 0.
+// ARGV[1] is the ID
+var priorityScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+if tonumber(ARGV[2]) == 0 then
+	redis.call('HDEL', KEYS[8], ARGV[1])
+else
+	redis.call('HSET', KEYS[8], ARGV[1], ARGV[2])
+end
+return 1
+`)
+
+// rescoreScript scores events anew, keeping the time they happened at. ARGV holds pairs of an ID
+// and its score, it returns the number of events rescored
+var rescoreScript = redis.NewScript(unread + `
+local rescored = 0
+for i = 1, #ARGV, 2 do
+	local score = redis.call('ZSCORE', KEYS[1], ARGV[i])
+	if score then
+		local at = time_of(ARGV[i], score)
+		redis.call('ZADD', KEYS[1], ARGV[i + 1], ARGV[i])
+		if tonumber(at) == tonumber(ARGV[i + 1]) then
+			redis.call('HDEL', KEYS[6], ARGV[i])
+		else
+			redis.call('HSET', KEYS[6], ARGV[i], at)
+		end
+		rescored = rescored + 1
+	end
+end
+return rescored
+`)
+
+// markScript flags events read or unread and moves the unread count by the change, it returns
+// the number of events whose state changed. ARGV[1] is "1" to mark read or "0" to mark unread,
+// the IDs follow
+var markScript = redis.NewScript(unread + `
+local lastRead = redis.call('HGET', KEYS[2], 'last_read')
+local want = 1 - tonumber(ARGV[1])
+local change, changed = 0, 0
+for i = 2, #ARGV do
+	local score = redis.call('ZSCORE', KEYS[1], ARGV[i])
+	if score then
+		local before = unread(ARGV[i], score)
+		if want == watermark(time_of(ARGV[i], score), lastRead) then
+			redis.call('HDEL', KEYS[4], ARGV[i])
+		else
+			redis.call('HSET', KEYS[4], ARGV[i], ARGV[1])
+		end
+		if before ~= want then
+			change = change + want - before
+			changed = changed + 1
+		end
+	end
+end
+redis.call('HINCRBY', KEYS[2], 'unread_count', change)
+return changed
+`)
+
+// markUpToScript marks the event ARGV[1] and every event that happened before it read. The
+// watermark moves past the event, the events sharing its time but sorting after it keep their
+// state in a flag
+var markUpToScript = redis.NewScript(unread + `
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score then
+	return 0
+end
+local at = tonumber(time_of(ARGV[1], score))
+local ties = redis.call('ZRANGEBYSCORE', KEYS[1], at, at)
+local times = redis.call('HGETALL', KEYS[6])
+for i = 1, #times, 2 do
+	if tonumber(times[i + 1]) == at then
+		ties[#ties + 1] = times[i]
+	end
+end
+local keep = {}
+for _, id in ipairs(ties) do
+	local tie = redis.call('ZSCORE', KEYS[1], id)
+	if tie and id > ARGV[1] and tonumber(time_of(id, tie)) == at then
+		keep[id] = unread(id, tie)
+	end
+end
+local lastRead = tonumber(redis.call('HGET', KEYS[2], 'last_read'))
+if not lastRead or lastRead < at + 1 then
+	lastRead = at + 1
+	redis.call('HSET', KEYS[2], 'last_read', lastRead)
+end
+local flags = redis.call('HKEYS', KEYS[4])
+for _, id in ipairs(flags) do
+	local flagged = redis.call('ZSCORE', KEYS[1], id)
+	if not flagged or tonumber(time_of(id, flagged)) <= at then
+		redis.call('HDEL', KEYS[4], id)
+	end
+end
+for id, state in pairs(keep) do
+	if state == 1 then
+		redis.call('HSET', KEYS[4], id, '0')
+	end
+end
+redis.call('HSET', KEYS[2], 'unread_count', count_unread(lastRead))
+return 1
+`)
+
+// resetScript moves the last read watermark to ARGV[1], dropping the flags of every event
+var resetScript = redis.NewScript(unread + `
+local created = redis.call('HSET', KEYS[2], 'last_read', ARGV[1])
+redis.call('DEL', KEYS[4])
+redis.call('HSET', KEYS[2], 'unread_count', count_unread(ARGV[1]))
+return created
+`)
+
+// recountScript recounts the unread events against the users last read into the unread count
+// and returns it, the meta hash of a user without events is left alone
+var recountScript = redis.NewScript(unread + `
+if redis.call('EXISTS', KEYS[1]) == 0 and redis.call('EXISTS', KEYS[2]) == 0 then
+	return 0
+end
+local count = count_unread(redis.call('HGET', KEYS[2], 'last_read'))
+redis.call('HSET', KEYS[2], 'unread_count', count)
+return count
+`)
+
+// wipeScript removes every key of the user and returns the number of events removed
+var wipeScript = redis.NewScript(`
+local count = redis.call('ZCARD', KEYS[1])
+redis.call('DEL', unpack(KEYS))
+return count
+`)
+
+// unreadScript counts the unread events against the last read ARGV[1], or the users last read
+// when it is not given. Every event not flagged read is unread when the user never read the feed
+var unreadScript = redis.NewScript(unread + `
+local lastRead = ARGV[1]
+if not lastRead then
+	lastRead = redis.call('HGET', KEYS[2], 'last_read')
+end
+return count_unread(lastRead)
+`)
+
+// withValues is the start of the scripts reading events. with_values returns the IDs and scores
+// read, as a flat list of pairs, along with the payload, the flag, the group state, the time, the
+// pin and the priority of each ID. Scripts reading several feeds pass the offset of the keys of
+// the feed in KEYS
+const withValues = `
+local function with_values(events, base)
+	base = base or 0
+	local ids = {}
+	for i = 1, #events, 2 do
+		ids[#ids + 1] = events[i]
+	end
+	if #ids == 0 then
+		return {events, {}, {}, {}, {}, {}, {}}
+	end
+	return {
+		events,
+		redis.call('HMGET', KEYS[base + 3], unpack(ids)),
+		redis.call('HMGET', KEYS[base + 4], unpack(ids)),
+		redis.call('HMGET', KEYS[base + 5], unpack(ids)),
+		redis.call('HMGET', KEYS[base + 6], unpack(ids)),
+		redis.call('HMGET', KEYS[base + 7], unpack(ids)),
+		redis.call('HMGET', KEYS[base + 8], unpack(ids)),
+	}
+end
+`
+
+// rangeScript reads the events ranked ARGV[1] to ARGV[2], newest first. When ARGV[3] is given
+// the events pinned at it come first
+var rangeScript = redis.NewScript(unread + withValues + `
+local pins = {}
+if ARGV[3] then
+	pins = pinned(tonumber(ARGV[3]))
+end
+if next(pins) == nil then
+	return with_values(redis.call('ZREVRANGE', KEYS[1], ARGV[1], ARGV[2], 'WITHSCORES'))
+end
+local first, rest = {}, {}
+local all = redis.call('ZREVRANGE', KEYS[1], 0, -1, 'WITHSCORES')
+for i = 1, #all, 2 do
+	local list = rest
+	if pins[all[i]] then
+		list = first
+	end
+	list[#list + 1] = all[i]
+	list[#list + 1] = all[i + 1]
+end
+for _, v in ipairs(rest) do
+	first[#first + 1] = v
+end
+local n = #first / 2
+local from, to = tonumber(ARGV[1]), tonumber(ARGV[2])
+if to < 0 then
+	to = n + to
+end
+local events = {}
+for rank = from, math.min(to, n - 1) do
+	events[#events + 1] = first[2 * rank + 1]
+	events[#events + 1] = first[2 * rank + 2]
+end
+return with_values(events)
+`)
+
+// betweenScript reads the events that happened at or after ARGV[1] and before ARGV[2] in the
+// order of the feed. Events are read by their score unless some are ranked
+var betweenScript = redis.NewScript(unread + withValues + `
+if redis.call('EXISTS', KEYS[6]) == 0 then
+	return with_values(redis.call('ZREVRANGEBYSCORE', KEYS[1], '(' .. ARGV[2], ARGV[1], 'WITHSCORES'))
+end
+local from, to = tonumber(ARGV[1]), tonumber(ARGV[2])
+local events = {}
+local all = redis.call('ZREVRANGE', KEYS[1], 0, -1, 'WITHSCORES')
+for i = 1, #all, 2 do
+	local at = tonumber(time_of(all[i], all[i + 1]))
+	if at >= from and at < to then
+		events[#events + 1] = all[i]
+		events[#events + 1] = all[i + 1]
+	end
+end
+return with_values(events)
+`)
+
+// afterScript reads the events scored at or below the cursor, newest first. ARGV[1] is the score
+// of the cursor and ARGV[2] the limit, events sharing the score may sit before the cursor so as
+// many extra events are returned for the caller to drop
+var afterScript = redis.NewScript(withValues + `
+local ties = redis.call('ZCOUNT', KEYS[1], ARGV[1], ARGV[1])
+return with_values(redis.call('ZREVRANGEBYSCORE', KEYS[1], ARGV[1], '-inf', 'WITHSCORES', 'LIMIT', 0, tonumber(ARGV[2]) + ties))
+`)
+
+// beforeScript is afterScript for the events scored at or above ARGV[1], oldest first
+var beforeScript = redis.NewScript(withValues + `
+local ties = redis.call('ZCOUNT', KEYS[1], ARGV[1], ARGV[1])
+return with_values(redis.call('ZRANGEBYSCORE', KEYS[1], ARGV[1], '+inf', 'WITHSCORES', 'LIMIT', 0, tonumber(ARGV[2]) + ties))
+`)
+
+// mergeScript reads the latest ARGV[1] events across several feeds of a user by the time they
+// happened at, every event when ARGV[1] is 0. KEYS holds the eight keys of each feed one feed
+// after the other. Feeds without ranked events only have their newest events read. The reply
+// is the reply of with_values for each feed, its events latest first
+var mergeScript = redis.NewScript(withValues + `
+local limit = tonumber(ARGV[1])
+local feeds = #KEYS / 8
+local candidates = {}
+for f = 0, feeds - 1 do
+	local data, times = KEYS[f * 8 + 1], KEYS[f * 8 + 6]
+	local last = -1
+	if limit > 0 and redis.call('EXISTS', times) == 0 then
+		last = limit - 1
+	end
+	local events = redis.call('ZREVRANGE', data, 0, last, 'WITHSCORES')
+	for i = 1, #events, 2 do
+		local at = redis.call('HGET', times, events[i]) or events[i + 1]
+		candidates[#candidates + 1] = {feed = f, id = events[i], score = events[i + 1], at = tonumber(at)}
+	end
+end
+table.sort(candidates, function(a, b)
+	if a.at ~= b.at then
+		return a.at > b.at
+	end
+	if a.id ~= b.id then
+		return a.id > b.id
+	end
+	return a.feed < b.feed
+end)
+if limit <= 0 or limit > #candidates then
+	limit = #candidates
+end
+local selected = {}
+for f = 0, feeds - 1 do
+	selected[f] = {}
+end
+for i = 1, limit do
+	local c = candidates[i]
+	local events = selected[c.feed]
+	events[#events + 1] = c.id
+	events[#events + 1] = c.score
+end
+local replies = {}
+for f = 0, feeds - 1 do
+	replies[f + 1] = with_values(selected[f], f * 8)
+end
+return replies
+`)
+
+// followScript adds the follow of ARGV[1] following ARGV[2] at ARGV[3] to the following set of
+// the follower in KEYS[1] and the followers set of the followee in KEYS[2]. It returns 0 when
+// the follow was already made, keeping its time
+var followScript = redis.NewScript(`
+if redis.call('ZADD', KEYS[1], 'NX', ARGV[3], ARGV[2]) == 0 then
+	return 0
+end
+redis.call('ZADD', KEYS[2], 'NX', ARGV[3], ARGV[1])
+return 1
+`)
+
+// unfollowScript removes the follow added by followScript, it returns 0 when there was none
+var unfollowScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[2]) == 0 then
+	return 0
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+return 1
+`)
+
+// followersAfterScript reads up to ARGV[3] followers in KEYS[1] that followed before the cursor,
+// the latest first, as a flat list of pairs of followers and scores. ARGV[1] is the score of the
+// cursor and ARGV[2] its follower, followers of the same score come after it when they sort
+// below it
+var followersAfterScript = redis.NewScript(`
+local at, limit = tonumber(ARGV[1]), tonumber(ARGV[3])
+local ties = redis.call('ZCOUNT', KEYS[1], at, at)
+local follows = redis.call('ZREVRANGEBYSCORE', KEYS[1], at, '-inf', 'WITHSCORES', 'LIMIT', 0, limit + ties)
+local page = {}
+for i = 1, #follows, 2 do
+	if #page == 2 * limit then
+		break
+	end
+	if tonumber(follows[i + 1]) < at or follows[i] < ARGV[2] then
+		page[#page + 1] = follows[i]
+		page[#page + 1] = follows[i + 1]
+	end
+end
+return page
+`)
+
+// scripts lists every script so a pipeline can load them before running them by their hash
+var scripts = []*redis.Script{
+	storeScript, groupScript, deleteScript, updateScript, moveScript, rescoreScript,
+	pinScript, unpinScript, priorityScript,
+	markScript, markUpToScript, resetScript, recountScript, wipeScript, unreadScript,
+	rangeScript, betweenScript, afterScript, beforeScript, mergeScript,
+	followScript, unfollowScript, followersAfterScript,
+}
@@ -0,0 +1,207 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MergeBackend is implemented by backends that can read several feeds of a user merged in a
+// single round trip. Timeline falls back to reading every feed and merging them itself for
+// other backends, or when a backend returns ErrNotSupported for the feeds it is given
+type MergeBackend interface {
+	// Merge reads the latest limit events of user across feeds by the time they happened at,
+	// every event when limit is 0. It returns the events of each feed, latest first
+	Merge(ctx context.Context, user string, feeds []Backend, limit int) ([][]Item, error)
+}
+
+// TimelineItem is an event of a timeline along with the name of the feed it was read from
+type TimelineItem struct {
+	Item
+	Feed string `json:"feed"`
+}
+
+// Timeline is the events of a user across several feeds, merged latest first by the time they
+// happened at. Events of the same second are ordered by ID, then by the order of the feeds
+type Timeline struct {
+	UserID string  `json:"user_id"`
+	Feeds  []*Feed `json:"feeds"`
+}
+
+// activity returns the activity of the user in the feed at i
+func (t Timeline) activity(i int) Activity {
+	return Activity{UserID: t.UserID, Feed: t.Feeds[i]}
+}
+
+// name returns the names of the feeds, for errors of the whole timeline
+func (t Timeline) name() string {
+	names := make([]string, len(t.Feeds))
+	for i, feed := range t.Feeds {
+		names[i] = feed.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// Peek returns a page of the timeline, latest first, without marking the feeds as read
+func (t Timeline) Peek(ctx context.Context, page, perPage int) ([]TimelineItem, error) {
+	if page < 1 || perPage < 1 {
+		return nil, opError("timeline", t.name(), t.UserID, fmt.Errorf("%w: page %d of %d", ErrInvalidPage, page, perPage))
+	}
+
+	items, err := t.merge(ctx, page*perPage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	from := (page - 1) * perPage
+	if from > len(items) {
+		from = len(items)
+	}
+
+	return items[from:], nil
+}
+
+// Read returns a page of the timeline, latest first, and marks every feed as read
+func (t Timeline) Read(ctx context.Context, page, perPage int) ([]TimelineItem, error) {
+
+	res, err := t.Peek(ctx, page, perPage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+
+	for i := range t.Feeds {
+		if _, err := t.activity(i).ResetLastRead(ctx, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// Paginate returns a page of the timeline and marks every feed as read, see Activity.Paginate
+func (t Timeline) Paginate(ctx context.Context, page, perPage int) ([]TimelineItem, error) {
+	return t.Read(ctx, page, perPage)
+}
+
+// All returns every event of the timeline, latest first
+func (t Timeline) All(ctx context.Context) ([]TimelineItem, error) {
+	return t.merge(ctx, 0)
+}
+
+// UnreadCount returns the sum of the unread counts of the feeds
+func (t Timeline) UnreadCount(ctx context.Context) (int64, error) {
+	var count int64
+
+	for i := range t.Feeds {
+		unread, err := t.activity(i).UnreadCount(ctx)
+
+		if err != nil {
+			return 0, err
+		}
+
+		count += unread
+	}
+
+	return count, nil
+}
+
+// merge reads the latest limit events across the feeds, every event when limit is 0. The
+// backend of the first feed merges them when it can, otherwise every feed is read whole
+func (t Timeline) merge(ctx context.Context, limit int) ([]TimelineItem, error) {
+	if len(t.Feeds) == 0 {
+		return []TimelineItem{}, nil
+	}
+
+	backends := make([]Backend, len(t.Feeds))
+	for i := range t.Feeds {
+		backends[i] = t.activity(i).backend()
+	}
+
+	if merger, ok := backends[0].(MergeBackend); ok {
+		feeds, err := merger.Merge(ctx, t.UserID, backends, limit)
+
+		if !errors.Is(err, ErrNotSupported) {
+			if err != nil {
+				return nil, err
+			}
+			return t.mergeItems(feeds, limit), nil
+		}
+	}
+
+	feeds := make([][]Item, len(backends))
+
+	for i, backend := range backends {
+		items, err := backend.All(ctx, t.UserID)
+
+		if err != nil && !errors.Is(err, ErrUserNotFound) {
+			return nil, err
+		}
+
+		// ranked and pinned events are read out of time order
+		sort.SliceStable(items, func(a, b int) bool {
+			return later(items[a], items[b])
+		})
+
+		feeds[i] = items
+	}
+
+	return t.mergeItems(feeds, limit), nil
+}
+
+// mergeItems merges the events of every feed, each latest first, into up to limit events of
+// the timeline, every event when limit is 0
+func (t Timeline) mergeItems(feeds [][]Item, limit int) []TimelineItem {
+	total := 0
+	for _, items := range feeds {
+		total += len(items)
+	}
+
+	if limit <= 0 || limit > total {
+		limit = total
+	}
+
+	merged := make([]TimelineItem, 0, limit)
+	heads := make([]int, len(feeds))
+
+	for len(merged) < limit {
+		next := -1
+
+		for i, items := range feeds {
+			if heads[i] == len(items) {
+				continue
+			}
+			if next < 0 || later(items[heads[i]], feeds[next][heads[next]]) {
+				next = i
+			}
+		}
+
+		merged = append(merged, TimelineItem{Item: feeds[next][heads[next]], Feed: t.Feeds[next].Name})
+		heads[next]++
+	}
+
+	return merged
+}
+
+// later reports whether a comes before b in a timeline, the latest first and by ID within
+// the same second
+func later(a, b Item) bool {
+	if !a.At.Equal(b.At) {
+		return a.At.After(b.At)
+	}
+	return a.ID > b.ID
+}
+
+// NewTimeline instantiates the timeline of a user across feeds
+func NewTimeline(id string, feeds ...*Feed) *Timeline {
+	return &Timeline{
+		UserID: id,
+		Feeds:  feeds,
+	}
+}
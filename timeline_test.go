@@ -0,0 +1,134 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis"
+	"github.com/google/go-cmp/cmp"
+)
+
+func timelineValues(items []TimelineItem) []string {
+	values := make([]string, len(items))
+	for i, item := range items {
+		values[i] = item.Feed + "/" + item.Value
+	}
+	return values
+}
+
+func TestTimeline(t *testing.T) {
+	ctx := context.Background()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	other, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer other.Close()
+
+	// mentions are ranked the other way round to their time, a timeline still reads them by time
+	reversed := ScorerFunc(func(action Action, now time.Time) float64 {
+		return -float64(action.At.Unix())
+	})
+
+	tests := []struct {
+		name     string
+		backends func(user string) []Backend
+	}{
+		{"redis", func(user string) []Backend {
+			b := NewRedisClientWithPrefix(c, user)
+			return []Backend{b, b, b}
+		}},
+		{"memory", func(user string) []Backend {
+			return []Backend{NewMemoryBackend(), NewMemoryBackend(), NewMemoryBackend()}
+		}},
+		{"other connection", func(user string) []Backend {
+			b := NewRedisClientWithPrefix(c, user)
+			return []Backend{b, NewRedisClient(redis.NewClient(&redis.Options{Addr: other.Addr()})), b}
+		}},
+		{"mixed", func(user string) []Backend {
+			b := NewRedisClientWithPrefix(c, user)
+			return []Backend{NewMemoryBackend(), b, b}
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backends := test.backends(test.name)
+
+			news := NewFeed("news", 10, 10, backends[0])
+			mentions := NewFeed("mentions", 10, 10, backends[1])
+			mentions.Scorer = reversed
+			alerts := NewFeed("alerts", 10, 10, backends[2])
+
+			NewActivity("okandas", news).Store(ctx, "n1", 100)
+			NewActivity("okandas", news).Store(ctx, "n2", 103)
+			NewActivity("okandas", mentions).Store(ctx, "m1", 101)
+			NewActivity("okandas", mentions).Store(ctx, "m2", 104)
+			// stored one after the other so the alert gets the larger ID of the second
+			NewActivity("okandas", news).Store(ctx, "n3", 105)
+			NewActivity("okandas", alerts).Store(ctx, "a1", 105)
+
+			timeline := NewTimeline("okandas", news, mentions, alerts)
+
+			all, err := timeline.All(ctx)
+			want := []string{"alerts/a1", "news/n3", "mentions/m2", "news/n2", "mentions/m1", "news/n1"}
+
+			if got := timelineValues(all); err != nil || !cmp.Equal(got, want) {
+				t.Errorf("all got %v, %v want %v", got, err, want)
+			}
+
+			pages := []struct {
+				page, perPage int
+				want          []string
+			}{
+				{1, 2, []string{"alerts/a1", "news/n3"}},
+				{2, 2, []string{"mentions/m2", "news/n2"}},
+				{2, 4, []string{"mentions/m1", "news/n1"}},
+				{3, 4, []string{}},
+			}
+
+			for _, p := range pages {
+				items, err := timeline.Peek(ctx, p.page, p.perPage)
+
+				if got := timelineValues(items); err != nil || !cmp.Equal(got, p.want) {
+					t.Errorf("page %d of %d got %v, %v want %v", p.page, p.perPage, got, err, p.want)
+				}
+			}
+
+			if _, err := timeline.Peek(ctx, 0, 2); !errors.Is(err, ErrInvalidPage) {
+				t.Errorf("got %v want %v", err, ErrInvalidPage)
+			}
+
+			if count, err := timeline.UnreadCount(ctx); err != nil || count != 6 {
+				t.Errorf("got %d, %v want 6 unread", count, err)
+			}
+
+			items, _ := timeline.Read(ctx, 1, 3)
+
+			if !items[0].Unread || items[0].At.Unix() != 105 {
+				t.Errorf("got %+v want the unread alert", items[0])
+			}
+
+			if count, err := timeline.UnreadCount(ctx); err != nil || count != 0 {
+				t.Errorf("got %d, %v want every feed read", count, err)
+			}
+		})
+	}
+
+	if items, err := NewTimeline("okandas").All(ctx); err != nil || len(items) != 0 {
+		t.Errorf("got %v, %v want an empty timeline", items, err)
+	}
+}